@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var databaseFormat string
+
+// The per-platform files of the bounty-targets-data datasets (https://github.com/arkadiyt/bounty-targets-data), and the asset types of each platform that can be matched against recon targets
+var bountyTargetsDataFiles = []struct {
+	filename string
+	isWeb    func(assetType string) bool
+}{
+	{"hackerone_data.json", func(assetType string) bool { return hackeroneWebAssetTypes[assetType] }},
+	{"bugcrowd_data.json", func(assetType string) bool { return bugcrowdWebCategories[assetType] }},
+	{"intigriti_data.json", func(assetType string) bool { return intigritiWebAssetTypes[strings.ToLower(assetType)] }},
+	{"yeswehack_data.json", func(assetType string) bool { return yeswehackWebScopeTypes[assetType] }},
+	{"federacy_data.json", func(assetType string) bool { return assetType == "website" || assetType == "api" }},
+}
+
+// bountyTargetsProgram is a program of any of the bounty-targets-data files. Every platform names some fields differently, so all of them are listed here.
+type bountyTargetsProgram struct {
+	Name string `json:"name"`
+	// Handle is only set by HackerOne and Intigriti
+	Handle string `json:"handle"`
+	// ID is the slug of YesWeHack programs. The other platforms use numbers or UUIDs.
+	ID      json.RawMessage `json:"id"`
+	URL     string          `json:"url"`
+	Targets struct {
+		InScope    []bountyTargetsTarget `json:"in_scope"`
+		OutOfScope []bountyTargetsTarget `json:"out_of_scope"`
+	} `json:"targets"`
+}
+
+type bountyTargetsTarget struct {
+	// HackerOne
+	AssetIdentifier string `json:"asset_identifier"`
+	AssetType       string `json:"asset_type"`
+	// Bugcrowd, YesWeHack and Federacy
+	Target string `json:"target"`
+	// Intigriti
+	Endpoint string `json:"endpoint"`
+	// Bugcrowd, Intigriti, YesWeHack and Federacy
+	Type string `json:"type"`
+}
+
+// Returns the slug of the program: the handle of HackerOne and Intigriti, the last part of the URL of Bugcrowd and Federacy ("https://bugcrowd.com/tesla"), or the id of YesWeHack
+func (p bountyTargetsProgram) slug() string {
+	if p.Handle != "" {
+		return p.Handle
+	}
+	if programURL, err := url.Parse(p.URL); err == nil && p.URL != "" {
+		if slug := path.Base(strings.TrimSuffix(programURL.Path, "/")); slug != "." && slug != "/" {
+			return slug
+		}
+	}
+	var id string
+	if err := json.Unmarshal(p.ID, &id); err == nil {
+		return id
+	}
+	return ""
+}
+
+func (t bountyTargetsTarget) identifier() string {
+	for _, identifier := range []string{t.AssetIdentifier, t.Target, t.Endpoint} {
+		if identifier != "" {
+			return identifier
+		}
+	}
+	return ""
+}
+
+func (t bountyTargetsTarget) assetType() string {
+	if t.AssetType != "" {
+		return t.AssetType
+	}
+	return t.Type
+}
+
+// Loads every bounty-targets-data file found in the directory as if it were a firebounty database, so that the programs can be searched with --company.
+// The files are never downloaded, so that a vetted snapshot can be used offline.
+func loadBountyTargetsData(directory string) (Firebounty, error) {
+	var database Firebounty
+	found := false
+
+	for _, dataFile := range bountyTargetsDataFiles {
+		path := filepath.Join(directory, dataFile.filename)
+		byteValue, err := os.ReadFile(path) // #nosec G304 -- path is in the database directory, which is a CLI argument specified by the user running the program.
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return Firebounty{}, &databaseError{"Couldn't read " + path, err}
+		}
+		found = true
+
+		var programs []bountyTargetsProgram
+		if err := json.Unmarshal(byteValue, &programs); err != nil {
+			return Firebounty{}, &databaseError{"Couldn't parse " + path, err}
+		}
+		for _, program := range programs {
+			database.Pgms = append(database.Pgms, bountyTargetsToProgram(program, dataFile.filename, path, dataFile.isWeb))
+		}
+	}
+
+	if !found {
+		filenames := make([]string, len(bountyTargetsDataFiles))
+		for i, dataFile := range bountyTargetsDataFiles {
+			filenames[i] = dataFile.filename
+		}
+		return Firebounty{}, &databaseError{"Couldn't find any bounty-targets-data file (" + strings.Join(filenames, ", ") + ") in \"" + directory + "\"", os.ErrNotExist}
+	}
+	return database, nil
+}
+
+// Converts a program into the firebounty format. Web assets get the "web_application" scope type, and the other assets keep the type of their platform, which is mapped by scopeAssetType.
+func bountyTargetsToProgram(program bountyTargetsProgram, source string, databasePath string, isWeb func(string) bool) Program {
+	converted := Program{
+		Slug:         program.slug(),
+		Url:          program.URL,
+		Name:         program.Name,
+		source:       source,
+		databasePath: databasePath,
+	}
+
+	toScopes := func(targets []bountyTargetsTarget) []Scope {
+		var scopes []Scope
+		for _, target := range targets {
+			scopeType := target.assetType()
+			if isWeb(scopeType) {
+				scopeType = "web_application"
+			}
+			//some identifiers are lists, such as "example.com, www.example.com"
+			for _, identifier := range strings.Split(target.identifier(), ",") {
+				if identifier = strings.TrimSpace(identifier); identifier != "" {
+					scopes = append(scopes, Scope{Scope: identifier, Scope_type: scopeType})
+				}
+			}
+		}
+		return scopes
+	}
+	converted.Scopes.In_scopes = toScopes(program.Targets.InScope)
+	converted.Scopes.Out_of_scopes = toScopes(program.Targets.OutOfScope)
+	return converted
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+)
+
+func Test_loadBountyTargetsData(t *testing.T) {
+	database, err := loadBountyTargetsData(filepath.Join("testdata", "bounty-targets-data"))
+	checkForErrors(t, err)
+	equals(t, 5, len(database.Pgms))
+
+	acme := database.Pgms[0]
+	equals(t, "Acme Corp", acme.Name)
+	equals(t, "acme", acme.Slug)
+	equals(t, "hackerone_data.json", acme.source)
+	equals(t, []Scope{
+		{Scope: "*.acme.com", Scope_type: "web_application"},
+		{Scope: "api.acme.io", Scope_type: "web_application"},
+		{Scope: "admin.acme.io", Scope_type: "web_application"},
+		{Scope: "com.acme.android", Scope_type: "GOOGLE_PLAY_APP_ID"},
+	}, acme.Scopes.In_scopes)
+
+	tesla := database.Pgms[1]
+	equals(t, "bugcrowd_data.json", tesla.source)
+	// the slugs of the other platforms come from their URL or id
+	equals(t, "tesla", tesla.Slug)
+	equals(t, "intigriti", database.Pgms[2].Slug)
+	equals(t, "yeswehack", database.Pgms[3].Slug)
+	equals(t, "federacy", database.Pgms[4].Slug)
+	equals(t, Scope{Scope: "*.tesla.com", Scope_type: "web_application"}, tesla.Scopes.In_scopes[0])
+	equals(t, Scope{Scope: "com.teslamotors.tesla", Scope_type: "android"}, tesla.Scopes.In_scopes[1])
+	equals(t, Scope{Scope: "shop.tesla.com", Scope_type: "web_application"}, tesla.Scopes.Out_of_scopes[0])
+
+	intigriti := database.Pgms[2]
+	equals(t, Scope{Scope: "203.0.113.0/24", Scope_type: "web_application"}, intigriti.Scopes.In_scopes[1])
+
+	// the converted programs are used just like the firebounty programs
+	setGlobal(t, &chainMode, true)
+	includes, excludes, err := parseCompany("acme", database, 0)
+	checkForErrors(t, err)
+	equals(t, 4, len(includes))
+	equals(t, "hackerone_data.json", includes[0].Source)
+	equals(t, "Acme Corp", includes[0].Program)
+	equals(t, 1, len(excludes))
+
+	// the android package is only matched against android targets
+	equals(t, scope.AndroidPackage, includes[3].Kind)
+	equals(t, "com.acme.android", includes[3].Identifier)
+
+	_, err = loadBountyTargetsData(t.TempDir())
+	equals(t, exitDatabase, exitCode(err))
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+)
+
+const bugcrowdDefaultURL = "https://bugcrowd.com"
+
+var bugcrowdURL string
+
+// bugcrowdTargetGroup is a group of targets of a Bugcrowd program, as returned by "https://bugcrowd.com/<program>/target_groups"
+type bugcrowdTargetGroup struct {
+	Name    string `json:"name"`
+	InScope bool   `json:"in_scope"`
+	// TargetsURL is usually a path relative to bugcrowdURL
+	TargetsURL string           `json:"targets_url"`
+	Targets    []bugcrowdTarget `json:"targets"`
+}
+
+type bugcrowdTarget struct {
+	Name     string `json:"name"`
+	URI      string `json:"uri"`
+	Category string `json:"category"`
+}
+
+// the target categories that are web hosts
+var bugcrowdWebCategories = map[string]bool{
+	"website": true,
+	"api":     true,
+	"network": true,
+}
+
+// Loads the target groups of a Bugcrowd program, and returns its in-scope and out-of-scope rules. The target groups are downloaded if the cached copy is missing or older than 24hs.
+func loadBugcrowdProgram(slug string) (includes []scope.Rule, excludes []scope.Rule, err error) {
+	cachePath := platformCachePath("bugcrowd", slug)
+	var groups []bugcrowdTargetGroup
+	cached, err := readPlatformCache(cachePath, &groups)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !cached {
+		credentials, err := loadCredentials("bugcrowd", "", "BUGCROWD_TOKEN")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !chainMode {
+			fmt.Println("[INFO]: Downloading the scopes of \"" + slug + "\" from Bugcrowd and saving them in \"" + cachePath + "\"")
+		}
+		groups, err = downloadBugcrowdTargetGroups(bugcrowdURL, slug, credentials.Token)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writePlatformCache(cachePath, groups); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !chainMode {
+		fmt.Print("[+] Loaded the Bugcrowd program " + string(colorGreen) + slug + string(colorReset) + "!\n")
+		fmt.Println("[+] Program URL: " + strings.TrimSuffix(bugcrowdURL, "/") + "/" + slug)
+
+		fmt.Println("[+] In-scope rules: ")
+		for _, group := range groups {
+			if group.InScope {
+				for _, target := range group.Targets {
+					fmt.Println("\t[+] " + target.Category + ": " + target.Name)
+				}
+			}
+		}
+
+		fmt.Println("\n[+] Out-of-scope rules: ")
+		for _, group := range groups {
+			if !group.InScope {
+				for _, target := range group.Targets {
+					fmt.Println("\t[+] " + target.Category + ": " + target.Name)
+				}
+			}
+		}
+
+		fmt.Println("\n[+] Analysis started...")
+	}
+
+	includes, excludes = bugcrowdRules(slug, groups)
+	return includes, excludes, nil
+}
+
+// Downloads the target groups of a program, and then the targets of every group
+func downloadBugcrowdTargetGroups(baseURL string, slug string, token string) ([]bugcrowdTargetGroup, error) {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, &usageError{"Invalid Bugcrowd URL " + baseURL, err}
+	}
+	authenticate := func(request *http.Request) {
+		//API keys look like "username:password". Everything else is the value of a "_bugcrowd_session" cookie.
+		if strings.Contains(token, ":") {
+			request.Header.Set("Authorization", "Token "+token)
+		} else {
+			request.AddCookie(&http.Cookie{Name: "_bugcrowd_session", Value: token})
+		}
+	}
+
+	body, err := platformGet("Bugcrowd", base.String()+"/"+url.PathEscape(slug)+"/target_groups", slug, authenticate)
+	if err != nil {
+		return nil, err
+	}
+	var page struct {
+		Groups []bugcrowdTargetGroup `json:"groups"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, &databaseError{"Couldn't parse the Bugcrowd target groups", err}
+	}
+
+	for i, group := range page.Groups {
+		if group.TargetsURL == "" {
+			continue
+		}
+		targetsURL, err := base.Parse(group.TargetsURL)
+		if err != nil {
+			return nil, &databaseError{"Bugcrowd returned an invalid targets URL: " + group.TargetsURL, err}
+		}
+		//the credentials are only sent to Bugcrowd
+		if targetsURL.Scheme != base.Scheme || targetsURL.Host != base.Host {
+			return nil, &databaseError{"Bugcrowd returned a targets URL outside of " + base.Host + ": " + group.TargetsURL, nil}
+		}
+
+		body, err := platformGet("Bugcrowd", targetsURL.String(), slug, authenticate)
+		if err != nil {
+			return nil, err
+		}
+		var targets struct {
+			Targets []bugcrowdTarget `json:"targets"`
+		}
+		if err := json.Unmarshal(body, &targets); err != nil {
+			return nil, &databaseError{"Couldn't parse the Bugcrowd targets of the group \"" + group.Name + "\"", err}
+		}
+		page.Groups[i].Targets = targets.Targets
+	}
+
+	return page.Groups, nil
+}
+
+// Maps the targets into rules. Every target of an out-of-scope group is out of scope.
+func bugcrowdRules(slug string, groups []bugcrowdTargetGroup) (includes []scope.Rule, excludes []scope.Rule) {
+	skippedScopes := make(map[string]int)
+	for _, group := range groups {
+		for _, target := range group.Targets {
+			//some target names are descriptions, such as "Tesla Account API". Their URI is used instead.
+			identifier := target.Name
+			if strings.Contains(identifier, " ") && target.URI != "" {
+				identifier = target.URI
+			}
+
+			rules := parsePlatformScope(identifier, target.Category, bugcrowdWebCategories[target.Category], "bugcrowd", slug, skippedScopes)
+			if group.InScope {
+				includes = append(includes, rules...)
+			} else {
+				excludes = append(excludes, rules...)
+			}
+		}
+	}
+	warnSkippedScopes(skippedScopes, slug)
+	return includes, excludes
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+)
+
+// newBugcrowdStub serves the recorded responses in testdata/bugcrowd, and requires either the "session" cookie or the "user:password" API key
+func newBugcrowdStub(tb testing.TB) *httptest.Server {
+	return newFixtureServer(tb, "bugcrowd", func(r *http.Request) bool {
+		cookie, err := r.Cookie("_bugcrowd_session")
+		return (err == nil && cookie.Value == "session") || r.Header.Get("Authorization") == "Token user:password"
+	})
+}
+
+func Test_downloadBugcrowdTargetGroups(t *testing.T) {
+	server := newBugcrowdStub(t)
+
+	groups, err := downloadBugcrowdTargetGroups(server.URL, "tesla", "session")
+	checkForErrors(t, err)
+	equals(t, 2, len(groups))
+	equals(t, 3, len(groups[0].Targets))
+	equals(t, "shop.tesla.com", groups[1].Targets[0].Name)
+
+	includes, excludes := bugcrowdRules("tesla", groups)
+	equals(t, 3, len(includes))
+	equals(t, "*.tesla.com", includes[0].Raw)
+	equals(t, "https://akamai-apigateway-vehicle.tesla.com", includes[1].Raw)
+	equals(t, "bugcrowd", includes[0].Source)
+	equals(t, "tesla", includes[0].Program)
+	// the Google Play URL of the app is used, since its name is a description
+	equals(t, scope.AndroidPackage, includes[2].Kind)
+	equals(t, "com.teslamotors.tesla", includes[2].Identifier)
+	equals(t, 1, len(excludes))
+
+	// API keys are sent in the Authorization header
+	_, err = downloadBugcrowdTargetGroups(server.URL+"/", "tesla", "user:password")
+	checkForErrors(t, err)
+
+	_, err = downloadBugcrowdTargetGroups(server.URL, "tesla", "expired")
+	equals(t, exitUsage, exitCode(err))
+
+	_, err = downloadBugcrowdTargetGroups(server.URL, "unknown", "session")
+	equals(t, true, errors.Is(err, errCompanyNotFound))
+
+	// the credentials aren't sent to the targets URLs of other hosts
+	_, err = downloadBugcrowdTargetGroups(server.URL, "leaky", "session")
+	equals(t, exitDatabase, exitCode(err))
+}
+
+func Test_loadBugcrowdProgram(t *testing.T) {
+	server := newBugcrowdStub(t)
+	setGlobal(t, &bugcrowdURL, server.URL)
+	setGlobal(t, &firebountyJSONPath, filepath.Join(t.TempDir(), firebountyJSONFilename))
+	setGlobal(t, &chainMode, true)
+	t.Setenv("BUGCROWD_TOKEN", "session")
+
+	includes, excludes, err := loadPlatformProgram("bugcrowd", "tesla")
+	checkForErrors(t, err)
+	equals(t, 3, len(includes))
+	equals(t, 1, len(excludes))
+
+	_, err = os.Stat(platformCachePath("bugcrowd", "tesla"))
+	checkForErrors(t, err)
+}
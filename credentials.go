@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const credentialsFilename = "credentials.json"
+
+// platformCredentials are the API credentials of a bug bounty platform
+type platformCredentials struct {
+	Username string `json:"username,omitempty"`
+	Token    string `json:"token"`
+}
+
+// Returns the API credentials of a platform. The environment variables take precedence over the "credentials.json" file that's next to the firebounty database, which looks like this:
+//
+//	{"hackerone": {"username": "...", "token": "..."}, "bugcrowd": {"token": "..."}, "intigriti": {"token": "..."}}
+//
+// Platforms that only need a token have no usernameEnv.
+func loadCredentials(platform string, usernameEnv string, tokenEnv string) (platformCredentials, error) {
+	credentials := platformCredentials{Token: os.Getenv(tokenEnv)}
+	if usernameEnv != "" {
+		credentials.Username = os.Getenv(usernameEnv)
+	}
+	complete := func() bool {
+		return credentials.Token != "" && (usernameEnv == "" || credentials.Username != "")
+	}
+	if complete() {
+		return credentials, nil
+	}
+
+	credentialsPath := filepath.Join(filepath.Dir(firebountyJSONPath), credentialsFilename)
+	missingCredentials := "Missing the API credentials of " + platform + ". Set the " + tokenEnv + " environment variable, or add the token to " + credentialsPath
+	if usernameEnv != "" {
+		missingCredentials = "Missing the API credentials of " + platform + ". Set the " + usernameEnv + " and " + tokenEnv + " environment variables, or add them to " + credentialsPath
+	}
+
+	byteValue, err := os.ReadFile(credentialsPath) // #nosec G304 -- credentialsPath is next to the database path, which is a CLI argument specified by the user running the program.
+	if errors.Is(err, os.ErrNotExist) {
+		return credentials, &usageError{missingCredentials, nil}
+	} else if err != nil {
+		return credentials, &ioError{"Could not read " + credentialsPath, err}
+	}
+
+	var credentialsFile map[string]platformCredentials
+	if err := json.Unmarshal(byteValue, &credentialsFile); err != nil {
+		return credentials, &usageError{"Couldn't parse " + credentialsPath, err}
+	}
+
+	//the environment variables may override a single field of the file
+	fileCredentials := credentialsFile[platform]
+	if credentials.Username == "" && usernameEnv != "" {
+		credentials.Username = fileCredentials.Username
+	}
+	if credentials.Token == "" {
+		credentials.Token = fileCredentials.Token
+	}
+	if !complete() {
+		return credentials, &usageError{missingCredentials, nil}
+	}
+	return credentials, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Exit codes of hacker-scoper. Scripts and CI pipelines rely on them, so they must never change.
+const (
+	// At least one target is in scope
+	exitInScope = 0
+	// None of the targets are in scope
+	exitNoneInScope = 1
+	// Invalid arguments, an unknown company, or missing scope files
+	exitUsage = 2
+	// A scope source (database or platform API) couldn't be downloaded, read or parsed
+	exitDatabase = 3
+	// A targets, scopes or output file couldn't be read or written
+	exitIO = 4
+	// The lint subcommand found problems in the scope files
+	exitLintIssues = 5
+)
+
+var errNoneInScope = errors.New("none of the targets are in scope")
+var errCompanyNotFound = errors.New("no company matched the search")
+var errAmbiguousCompany = errors.New("more than one company matched the search")
+
+// usageError is returned when hacker-scoper was used incorrectly
+type usageError struct {
+	message string
+	err     error
+}
+
+func (e *usageError) Error() string { return describeError(e.message, e.err) }
+func (e *usageError) Unwrap() error { return e.err }
+
+// databaseError is returned when the firebounty database or the scopes of a platform couldn't be downloaded, read or parsed
+type databaseError struct {
+	message string
+	err     error
+}
+
+func (e *databaseError) Error() string { return describeError(e.message, e.err) }
+func (e *databaseError) Unwrap() error { return e.err }
+
+// ioError is returned when a targets, scopes or output file couldn't be read or written
+type ioError struct {
+	message string
+	err     error
+}
+
+func (e *ioError) Error() string { return describeError(e.message, e.err) }
+func (e *ioError) Unwrap() error { return e.err }
+
+func describeError(message string, err error) string {
+	if err == nil {
+		return message
+	}
+	return message + ": " + err.Error()
+}
+
+// Returns the exit code that corresponds to the error returned by run()
+func exitCode(err error) int {
+	var usageErr *usageError
+	var databaseErr *databaseError
+
+	switch {
+	case err == nil:
+		return exitInScope
+	case errors.Is(err, errNoneInScope):
+		return exitNoneInScope
+	case errors.Is(err, errLintIssues):
+		return exitLintIssues
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.As(err, &databaseErr):
+		return exitDatabase
+	default:
+		//every other error comes from reading or writing files
+		return exitIO
+	}
+}
+
+func printError(err error) {
+	fmt.Fprint(os.Stderr, colorRed+"[ERROR]: "+err.Error()+colorReset+"\n")
+}
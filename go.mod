@@ -7,3 +7,5 @@ toolchain go1.23.3
 require golang.org/x/net v0.40.0
 
 require github.com/zenizh/go-capturer v0.0.0-20211219060012-52ea6c8fed04
+
+require golang.org/x/text v0.25.0
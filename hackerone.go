@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+)
+
+const hackeroneDefaultAPIURL = "https://api.hackerone.com/v1"
+
+// the most pages of structured scopes that are downloaded. Pages have 100 scopes, so no real program comes close.
+const hackeroneMaxPages = 100
+
+var hackeroneMode bool
+var hackeroneAPIURL string
+
+// hackeroneScope is a structured scope of a HackerOne program, as returned by the hacker API:
+// https://api.hackerone.com/hacker-resources/#programs-get-structured-scopes
+type hackeroneScope struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		AssetType             string `json:"asset_type"`
+		AssetIdentifier       string `json:"asset_identifier"`
+		EligibleForSubmission bool   `json:"eligible_for_submission"`
+		EligibleForBounty     bool   `json:"eligible_for_bounty"`
+		Instruction           string `json:"instruction"`
+	} `json:"attributes"`
+}
+
+type hackeroneScopesPage struct {
+	Data  []hackeroneScope `json:"data"`
+	Links struct {
+		Next string `json:"next"`
+	} `json:"links"`
+}
+
+// the asset types that are web hosts
+var hackeroneWebAssetTypes = map[string]bool{
+	"URL":        true,
+	"WILDCARD":   true,
+	"CIDR":       true,
+	"IP_ADDRESS": true,
+}
+
+// Loads the structured scopes of a HackerOne program, and returns its in-scope and out-of-scope rules. The scopes are downloaded if the cached copy is missing or older than 24hs.
+func loadHackerOneProgram(handle string) (includes []scope.Rule, excludes []scope.Rule, err error) {
+	cachePath := platformCachePath("hackerone", handle)
+	var scopes []hackeroneScope
+	cached, err := readPlatformCache(cachePath, &scopes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !cached {
+		credentials, err := loadCredentials("hackerone", "HACKERONE_USERNAME", "HACKERONE_API_TOKEN")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !chainMode {
+			fmt.Println("[INFO]: Downloading the scopes of \"" + handle + "\" from HackerOne and saving them in \"" + cachePath + "\"")
+		}
+		scopes, err = downloadHackerOneScopes(hackeroneAPIURL, handle, credentials)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writePlatformCache(cachePath, scopes); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !chainMode {
+		fmt.Print("[+] Loaded the HackerOne program " + string(colorGreen) + handle + string(colorReset) + "!\n")
+		fmt.Println("[+] Program URL: https://hackerone.com/" + handle)
+
+		fmt.Println("[+] In-scope rules: ")
+		for _, s := range scopes {
+			if s.Attributes.EligibleForSubmission {
+				fmt.Println("\t[+] " + s.Attributes.AssetType + ": " + s.Attributes.AssetIdentifier)
+			}
+		}
+
+		fmt.Println("\n[+] Out-of-scope rules: ")
+		for _, s := range scopes {
+			if !s.Attributes.EligibleForSubmission {
+				fmt.Println("\t[+] " + s.Attributes.AssetType + ": " + s.Attributes.AssetIdentifier)
+			}
+		}
+
+		fmt.Println("\n[+] Analysis started...")
+	}
+
+	includes, excludes = hackeroneRules(handle, scopes)
+	return includes, excludes, nil
+}
+
+// Downloads every structured scope of a program, following the pagination links. The links must point to the API, since the credentials are sent to them.
+func downloadHackerOneScopes(apiURL string, handle string, credentials platformCredentials) ([]hackeroneScope, error) {
+	base, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, &usageError{"Invalid HackerOne API URL " + apiURL, err}
+	}
+	scopes := []hackeroneScope{}
+	next := strings.TrimSuffix(apiURL, "/") + "/hackers/programs/" + url.PathEscape(handle) + "/structured_scopes?page%5Bsize%5D=100"
+	seen := make(map[string]bool)
+
+	for next != "" {
+		if seen[next] {
+			//the pagination links loop back to a page that was already downloaded
+			break
+		}
+		if len(seen) == hackeroneMaxPages {
+			return nil, &databaseError{"HackerOne returned more than " + strconv.Itoa(hackeroneMaxPages) + " pages of scopes for \"" + handle + "\"", nil}
+		}
+		nextURL, err := url.Parse(next)
+		if err != nil || nextURL.Scheme != base.Scheme || nextURL.Host != base.Host {
+			return nil, &databaseError{"HackerOne returned a pagination link outside of the API: " + next, err}
+		}
+		seen[next] = true
+
+		body, err := platformGet("HackerOne", next, handle, func(request *http.Request) {
+			request.SetBasicAuth(credentials.Username, credentials.Token)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var page hackeroneScopesPage
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, &databaseError{"Couldn't parse the HackerOne scopes", err}
+		}
+		scopes = append(scopes, page.Data...)
+		next = page.Links.Next
+	}
+
+	return scopes, nil
+}
+
+// Maps the structured scopes into rules. Scopes that are not eligible for submission are out of scope.
+func hackeroneRules(handle string, scopes []hackeroneScope) (includes []scope.Rule, excludes []scope.Rule) {
+	skippedScopes := make(map[string]int)
+	for _, s := range scopes {
+		//some asset identifiers are lists, such as "example.com, www.example.com"
+		identifiers := strings.FieldsFunc(s.Attributes.AssetIdentifier, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\n'
+		})
+		for _, identifier := range identifiers {
+			rules := parsePlatformScope(identifier, s.Attributes.AssetType, hackeroneWebAssetTypes[s.Attributes.AssetType], "hackerone", handle, skippedScopes)
+			if s.Attributes.EligibleForSubmission {
+				includes = append(includes, rules...)
+			} else {
+				excludes = append(excludes, rules...)
+			}
+		}
+	}
+	warnSkippedScopes(skippedScopes, handle)
+	return includes, excludes
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+)
+
+// newHackerOneStub serves two pages of structured scopes for the "acme" program, and requires the "user:token" credentials
+func newHackerOneStub(tb testing.TB) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, token, ok := r.BasicAuth()
+		if !ok || username != "user" || token != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/v1/hackers/programs/acme/structured_scopes" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page[number]") == "2" {
+			_, _ = w.Write([]byte(`{"data": [
+				{"id": "3", "attributes": {"asset_type": "CIDR", "asset_identifier": "203.0.113.0/24", "eligible_for_submission": true}},
+				{"id": "4", "attributes": {"asset_type": "GOOGLE_PLAY_APP_ID", "asset_identifier": "com.acme.app", "eligible_for_submission": true}}
+			], "links": {}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": [
+			{"id": "1", "attributes": {"asset_type": "WILDCARD", "asset_identifier": "*.acme.com", "eligible_for_submission": true}},
+			{"id": "2", "attributes": {"asset_type": "URL", "asset_identifier": "blog.acme.com, status.acme.com", "eligible_for_submission": false}}
+		], "links": {"next": "` + server.URL + `/v1/hackers/programs/acme/structured_scopes?page%5Bnumber%5D=2"}}`))
+	}))
+	tb.Cleanup(server.Close)
+	return server
+}
+
+func Test_downloadHackerOneScopes(t *testing.T) {
+	server := newHackerOneStub(t)
+
+	scopes, err := downloadHackerOneScopes(server.URL+"/v1/", "acme", platformCredentials{"user", "token"})
+	checkForErrors(t, err)
+	equals(t, 4, len(scopes))
+	equals(t, "203.0.113.0/24", scopes[2].Attributes.AssetIdentifier)
+
+	includes, excludes := hackeroneRules("acme", scopes)
+	equals(t, 3, len(includes))
+	equals(t, "*.acme.com", includes[0].Raw)
+	// the mobile apps are matched with --target-type
+	equals(t, scope.AndroidPackage, includes[2].Kind)
+	equals(t, "com.acme.app", includes[2].Identifier)
+	equals(t, "hackerone", includes[0].Source)
+	equals(t, "acme", includes[0].Program)
+	equals(t, 2, len(excludes))
+	equals(t, "status.acme.com", excludes[1].Raw)
+
+	_, err = downloadHackerOneScopes(server.URL+"/v1", "acme", platformCredentials{"user", "wrong"})
+	equals(t, exitUsage, exitCode(err))
+
+	_, err = downloadHackerOneScopes(server.URL+"/v1", "unknown", platformCredentials{"user", "token"})
+	equals(t, true, errors.Is(err, errCompanyNotFound))
+}
+
+func Test_downloadHackerOneScopesLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next := "http://" + r.Host + r.URL.Path
+		if strings.Contains(r.URL.Path, "/leaky/") {
+			next = "https://attacker.example/v1/hackers/programs/leaky/structured_scopes"
+		}
+		_, _ = w.Write([]byte(`{"data": [{"id": "1", "attributes": {"asset_type": "URL", "asset_identifier": "example.com", "eligible_for_submission": true}}], "links": {"next": "` + next + `"}}`))
+	}))
+	defer server.Close()
+
+	// the pagination links that loop back are only followed once
+	scopes, err := downloadHackerOneScopes(server.URL+"/v1", "loop", platformCredentials{"user", "token"})
+	checkForErrors(t, err)
+	equals(t, 2, len(scopes))
+
+	// the credentials aren't sent to other hosts
+	_, err = downloadHackerOneScopes(server.URL+"/v1", "leaky", platformCredentials{"user", "token"})
+	equals(t, exitDatabase, exitCode(err))
+}
+
+func Test_loadHackerOneProgram(t *testing.T) {
+	server := newHackerOneStub(t)
+	setGlobal(t, &hackeroneAPIURL, server.URL+"/v1")
+	setGlobal(t, &firebountyJSONPath, filepath.Join(t.TempDir(), firebountyJSONFilename))
+	setGlobal(t, &chainMode, true)
+	t.Setenv("HACKERONE_USERNAME", "user")
+	t.Setenv("HACKERONE_API_TOKEN", "token")
+
+	includes, excludes, err := loadHackerOneProgram("acme")
+	checkForErrors(t, err)
+	equals(t, 3, len(includes))
+	equals(t, 2, len(excludes))
+
+	// the second lookup is served from the cache, so it doesn't need the API
+	_, err = os.Stat(platformCachePath("hackerone", "acme"))
+	checkForErrors(t, err)
+	server.Close()
+	includes, _, err = loadHackerOneProgram("acme")
+	checkForErrors(t, err)
+	equals(t, 3, len(includes))
+
+	_, _, err = loadPlatformProgram("hackerone", "../acme")
+	equals(t, exitUsage, exitCode(err))
+}
+
+func Test_loadCredentials(t *testing.T) {
+	setGlobal(t, &firebountyJSONPath, filepath.Join(t.TempDir(), firebountyJSONFilename))
+	t.Setenv("HACKERONE_USERNAME", "")
+	t.Setenv("HACKERONE_API_TOKEN", "")
+
+	_, err := loadCredentials("hackerone", "HACKERONE_USERNAME", "HACKERONE_API_TOKEN")
+	equals(t, exitUsage, exitCode(err))
+
+	credentialsPath := filepath.Join(filepath.Dir(firebountyJSONPath), credentialsFilename)
+	checkForErrors(t, os.WriteFile(credentialsPath, []byte(`{"hackerone": {"username": "user", "token": "token"}}`), 0600))
+	credentials, err := loadCredentials("hackerone", "HACKERONE_USERNAME", "HACKERONE_API_TOKEN")
+	checkForErrors(t, err)
+	equals(t, platformCredentials{"user", "token"}, credentials)
+
+	// the environment takes precedence over the file
+	t.Setenv("HACKERONE_API_TOKEN", "other")
+	credentials, err = loadCredentials("hackerone", "HACKERONE_USERNAME", "HACKERONE_API_TOKEN")
+	checkForErrors(t, err)
+	equals(t, platformCredentials{"user", "other"}, credentials)
+}
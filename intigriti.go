@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+)
+
+const intigritiDefaultAPIURL = "https://api.intigriti.com/external/researcher/v1"
+
+var intigritiAPIURL string
+
+// intigritiProgram is a program of the researcher API: https://api.intigriti.com/external/researcher/swagger/index.html
+type intigritiProgram struct {
+	ID      string `json:"id"`
+	Handle  string `json:"handle"`
+	Name    string `json:"name"`
+	Domains struct {
+		Content []intigritiDomain `json:"content"`
+	} `json:"domains"`
+}
+
+// intigritiDomain is an asset of a program. Its type is one of "Url", "Wildcard", "IpRange", "Android", "iOS", "Device" or "Other".
+type intigritiDomain struct {
+	Type     intigritiValue `json:"type"`
+	Endpoint string         `json:"endpoint"`
+	// Tier is the bounty tier of the asset, such as "Tier 1" or "Out Of Scope"
+	Tier intigritiValue `json:"tier"`
+}
+
+type intigritiValue struct {
+	ID    int    `json:"id"`
+	Value string `json:"value"`
+}
+
+// the asset types that are web hosts, lowercase'd
+var intigritiWebAssetTypes = map[string]bool{
+	"url":      true,
+	"wildcard": true,
+	"iprange":  true,
+}
+
+// Loads the domains of an Intigriti program, and returns its in-scope and out-of-scope rules. The domains are downloaded if the cached copy is missing or older than 24hs.
+func loadIntigritiProgram(handle string) (includes []scope.Rule, excludes []scope.Rule, err error) {
+	cachePath := platformCachePath("intigriti", handle)
+	var program intigritiProgram
+	cached, err := readPlatformCache(cachePath, &program)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !cached {
+		credentials, err := loadCredentials("intigriti", "", "INTIGRITI_TOKEN")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !chainMode {
+			fmt.Println("[INFO]: Downloading the scopes of \"" + handle + "\" from Intigriti and saving them in \"" + cachePath + "\"")
+		}
+		program, err = downloadIntigritiProgram(intigritiAPIURL, handle, credentials.Token)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writePlatformCache(cachePath, program); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !chainMode {
+		fmt.Print("[+] Loaded the Intigriti program " + string(colorGreen) + program.Name + string(colorReset) + "!\n")
+		fmt.Println("[+] Program URL: https://app.intigriti.com/researcher/programs/" + handle)
+
+		fmt.Println("[+] In-scope rules: ")
+		for _, domain := range program.Domains.Content {
+			if !isIntigritiOutOfScope(domain) {
+				fmt.Println("\t[+] " + domain.Type.Value + ": " + domain.Endpoint)
+			}
+		}
+
+		fmt.Println("\n[+] Out-of-scope rules: ")
+		for _, domain := range program.Domains.Content {
+			if isIntigritiOutOfScope(domain) {
+				fmt.Println("\t[+] " + domain.Type.Value + ": " + domain.Endpoint)
+			}
+		}
+
+		fmt.Println("\n[+] Analysis started...")
+	}
+
+	includes, excludes = intigritiRules(handle, program)
+	return includes, excludes, nil
+}
+
+// Finds the ID of the program by its handle, and then downloads the program with its domains
+func downloadIntigritiProgram(apiURL string, handle string, token string) (intigritiProgram, error) {
+	apiURL = strings.TrimSuffix(apiURL, "/")
+	authenticate := func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	//the programs are listed in pages of up to 500 programs
+	programID := ""
+	for offset := 0; programID == ""; {
+		body, err := platformGet("Intigriti", apiURL+"/programs?limit=500&offset="+strconv.Itoa(offset), handle, authenticate)
+		if err != nil {
+			return intigritiProgram{}, err
+		}
+		var page struct {
+			MaxCount int                `json:"maxCount"`
+			Records  []intigritiProgram `json:"records"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return intigritiProgram{}, &databaseError{"Couldn't parse the Intigriti programs", err}
+		}
+
+		for _, program := range page.Records {
+			if strings.EqualFold(program.Handle, handle) {
+				programID = program.ID
+				break
+			}
+		}
+
+		offset += len(page.Records)
+		if programID == "" && (len(page.Records) == 0 || offset >= page.MaxCount) {
+			return intigritiProgram{}, &usageError{"Unable to find the Intigriti program \"" + handle + "\"", errCompanyNotFound}
+		}
+	}
+
+	body, err := platformGet("Intigriti", apiURL+"/programs/"+url.PathEscape(programID), handle, authenticate)
+	if err != nil {
+		return intigritiProgram{}, err
+	}
+	var program intigritiProgram
+	if err := json.Unmarshal(body, &program); err != nil {
+		return intigritiProgram{}, &databaseError{"Couldn't parse the Intigriti program \"" + handle + "\"", err}
+	}
+	return program, nil
+}
+
+func isIntigritiOutOfScope(domain intigritiDomain) bool {
+	return strings.EqualFold(domain.Tier.Value, "Out Of Scope")
+}
+
+// Maps the domains into rules. Domains of the "Out Of Scope" tier are out of scope.
+func intigritiRules(handle string, program intigritiProgram) (includes []scope.Rule, excludes []scope.Rule) {
+	skippedScopes := make(map[string]int)
+	for _, domain := range program.Domains.Content {
+		rules := parsePlatformScope(strings.TrimSpace(domain.Endpoint), domain.Type.Value, intigritiWebAssetTypes[strings.ToLower(domain.Type.Value)], "intigriti", handle, skippedScopes)
+		if isIntigritiOutOfScope(domain) {
+			excludes = append(excludes, rules...)
+		} else {
+			includes = append(includes, rules...)
+		}
+	}
+	warnSkippedScopes(skippedScopes, handle)
+	return includes, excludes
+}
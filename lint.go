@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+	"golang.org/x/net/publicsuffix"
+)
+
+var lintMode bool
+
+var errLintIssues = errors.New("problem(s) found in the scope files")
+
+// lintIssue is a problem found in a line of a scope file. It's also the JSON representation of the problem.
+type lintIssue struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// Scope is the line exactly as it was written
+	Scope string `json:"scope"`
+	// Check is one of "invalid", "no-public-suffix", "too-broad", "duplicate", "redundant", "contradiction" or "shadowed"
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Statically checks every line of the scope files, and prints the problems found. If no files are given, the .inscope and .noscope files are checked.
+func runLint(paths []string) error {
+	if outputFormat != "text" && outputFormat != "json" && outputFormat != "jsonl" {
+		return &usageError{"Invalid output format selected: " + outputFormat, nil}
+	}
+
+	if len(paths) == 0 {
+		for _, filename := range []string{".inscope", ".noscope"} {
+			if path, err := searchForFileBackwards(filename); err == nil {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) == 0 {
+			return &usageError{"No scope files specified, and couldn't locate a .inscope or .noscope file", nil}
+		}
+	}
+
+	issues, err := lintFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	switch outputFormat {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(issues); err != nil {
+			return &ioError{"Couldn't write the lint report", err}
+		}
+	case "jsonl":
+		encoder := json.NewEncoder(os.Stdout)
+		for _, issue := range issues {
+			if err := encoder.Encode(issue); err != nil {
+				return &ioError{"Couldn't write the lint report", err}
+			}
+		}
+	default:
+		for _, issue := range issues {
+			color := colorYellow
+			if issue.Severity == "error" {
+				color = colorRed
+			}
+			fmt.Println(issue.File + ":" + strconv.Itoa(issue.Line) + ": " + color + issue.Check + colorReset + ": " + issue.Message)
+		}
+		if len(issues) == 0 {
+			fmt.Println(colorGreen + "[+] No problems found in " + strings.Join(paths, ", ") + colorReset)
+		}
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%d %w", len(issues), errLintIssues)
+	}
+	return nil
+}
+
+// scopeLinter collects the problems and the rules of the scope files
+type scopeLinter struct {
+	issues []lintIssue
+	// includes and excludes are the rules of every linted file, so the in-scope and out-of-scope lists can be compared with each other
+	includes []scope.Rule
+	excludes []scope.Rule
+	// files are the linted files, as they're named in the issues. The value is their position.
+	files map[string]int
+	// linted are the absolute paths of the linted files, so that a file included through different paths is linted once
+	linted map[string]bool
+}
+
+// Checks every line of the scope files and of the files they include, and then looks for contradicting, duplicated and redundant scopes across all of them.
+// The files whose name ends with "noscope" (such as ".noscope") are out-of-scope lists.
+func lintFiles(paths []string) ([]lintIssue, error) {
+	linter := &scopeLinter{issues: []lintIssue{}, files: make(map[string]int), linted: make(map[string]bool)}
+	for _, path := range paths {
+		if err := linter.lintFile(path, isNoscopeFile(path), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	//the rules are compared exactly as they were written
+	matcher, err := scope.NewMatcher(linter.includes, linter.excludes, scope.Options{ExplicitLevel: 2})
+	if err != nil {
+		return nil, err
+	}
+	for _, conflict := range matcher.Conflicts() {
+		other := "line " + strconv.Itoa(conflict.Other.Line)
+		if conflict.Other.Source != conflict.Rule.Source {
+			other = conflict.Other.Source + ":" + strconv.Itoa(conflict.Other.Line)
+		}
+		linter.issues = append(linter.issues, lintIssue{
+			File:     conflict.Rule.Source,
+			Line:     conflict.Rule.Line,
+			Scope:    conflict.Rule.Raw,
+			Check:    conflict.Kind.String(),
+			Severity: "warning",
+			Message:  conflict.Detail + " (" + other + ")",
+		})
+	}
+
+	issues := linter.issues
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return linter.files[issues[i].File] < linter.files[issues[j].File]
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues, nil
+}
+
+// Checks every line of a scope file, and lints the files that it includes. The negated ("!") scopes are the opposite of the rest of the file.
+func (l *scopeLinter) lintFile(path string, exclude bool, ancestors []string) error {
+	absolutePath, err := includedPath(path, ancestors)
+	if err != nil {
+		return err
+	}
+	if l.linted[absolutePath] {
+		//the file was already included by another file
+		return nil
+	}
+	l.linted[absolutePath] = true
+	l.files[path] = len(l.files)
+
+	scopesFile, err := os.Open(path) // #nosec G304 -- path is a CLI argument specified by the user running the program, or a file included by one.
+	if err != nil {
+		return &usageError{"Could not open " + path, err}
+	}
+	defer scopesFile.Close() // #nosec G307 -- The file is only read from.
+
+	lineNumber := 0
+	scopesScanner := bufio.NewScanner(scopesFile)
+	for scopesScanner.Scan() {
+		lineNumber++
+		text := scopesScanner.Text()
+
+		newIssue := func(check string, severity string, message string) lintIssue {
+			return lintIssue{File: path, Line: lineNumber, Scope: text, Check: check, Severity: severity, Message: message}
+		}
+
+		line, err := parseScopeLine(text)
+		if err != nil {
+			l.issues = append(l.issues, newIssue("invalid", "error", err.Error()))
+			continue
+		}
+		if line.empty {
+			continue
+		}
+		if line.include != "" {
+			//included paths are relative to the directory of the file that includes them
+			pattern := line.include
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(path), pattern)
+			}
+			matches, err := filepath.Glob(pattern)
+			if err != nil || len(matches) == 0 {
+				l.issues = append(l.issues, newIssue("invalid", "error", "no files matched the include "+strconv.Quote(line.include)))
+				continue
+			}
+			for _, match := range matches {
+				err := l.lintFile(match, exclude, append(slices.Clone(ancestors), absolutePath))
+				var usageErr *usageError
+				if errors.As(err, &usageErr) {
+					//include cycles and unreadable files are problems of the include line
+					l.issues = append(l.issues, newIssue("invalid", "error", err.Error()))
+				} else if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		rule := line.rule
+		rule.Source = path
+		rule.Line = lineNumber
+		if line.negated != exclude {
+			l.excludes = append(l.excludes, rule)
+		} else {
+			l.includes = append(l.includes, rule)
+		}
+
+		host := ruleDomain(rule)
+		if message := invalidHost(host); host != "" && message != "" {
+			l.issues = append(l.issues, newIssue("invalid", "error", message))
+		} else if message := tooBroad(rule); message != "" {
+			l.issues = append(l.issues, newIssue("too-broad", "warning", message))
+		} else if host != "" && !hasPublicSuffix(host) {
+			l.issues = append(l.issues, newIssue("no-public-suffix", "warning", strconv.Quote(host)+" does not have a public Top Level Domain (TLD)"))
+		}
+	}
+	if err := scopesScanner.Err(); err != nil {
+		return &ioError{"Could not read " + path + " successfully", err}
+	}
+	return nil
+}
+
+// Out-of-scope lists are named ".noscope", or end with "noscope"
+func isNoscopeFile(path string) bool {
+	return strings.HasSuffix(filepath.Base(path), "noscope")
+}
+
+// Returns why a hostname can't exist, such as "foo..example.com" or "-example.com". Returns an empty string for valid hostnames.
+func invalidHost(host string) string {
+	for _, label := range strings.Split(host, ".") {
+		switch {
+		case label == "":
+			return strconv.Quote(host) + " has an empty label"
+		case len(label) > 63:
+			return strconv.Quote(host) + " has a label longer than 63 characters"
+		case strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-"):
+			return strconv.Quote(host) + " has a label that starts or ends with a hyphen"
+		case strings.IndexFunc(label, func(r rune) bool { return !isHostnameRune(r) }) >= 0:
+			return strconv.Quote(host) + " has invalid characters"
+		}
+	}
+	return ""
+}
+
+// Internationalized hostnames are normalized to punycode by the scope package, so only ASCII letters, digits, hyphens and underscores are valid
+func isHostnameRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_'
+}
+
+// Returns why a rule covers way more than a single organization, such as "*.com" or "10.0.0.0/8". Returns an empty string for every other rule.
+func tooBroad(rule scope.Rule) string {
+	switch rule.Kind {
+	case scope.Wildcard:
+		if publicsuffix.List.PublicSuffix(rule.Host) == rule.Host {
+			return "the wildcard covers every domain of the public suffix \"" + rule.Host + "\""
+		}
+	case scope.Pattern:
+		suffix := patternSuffix(rule.Raw)
+		if suffix == "" || publicsuffix.List.PublicSuffix(suffix) == suffix {
+			return "the pattern covers domains of many organizations"
+		}
+	case scope.CIDR:
+		_, network, err := net.ParseCIDR(strings.TrimSpace(rule.Raw))
+		if err != nil {
+			return ""
+		}
+		ones, bits := network.Mask.Size()
+		if (bits == 32 && ones < 16) || (bits == 128 && ones < 32) {
+			return "the network /" + strconv.Itoa(ones) + " covers more than a single organization"
+		}
+	}
+	return ""
+}
+
+// Returns the domain that must have a public suffix. IP addresses have no domain.
+func ruleDomain(rule scope.Rule) string {
+	switch rule.Kind {
+	case scope.Exact, scope.Wildcard:
+		return rule.Host
+	case scope.Pattern:
+		return patternSuffix(rule.Raw)
+	}
+	return ""
+}
+
+// Returns the literal domain after the last wildcard of a pattern ("amzn*.example.com" -> "example.com")
+func patternSuffix(raw string) string {
+	host := strings.TrimSpace(raw)
+	if _, afterScheme, found := strings.Cut(host, "://"); found {
+		host = afterScheme
+	}
+	host, _, _ = strings.Cut(host, "/")
+	host, _, _ = strings.Cut(host, ":")
+	host = host[strings.LastIndexByte(host, '*')+1:]
+	return strings.ToLower(strings.Trim(host, "."))
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_lintFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".inscope")
+	scopes := "*.example.com\n" +
+		"\n" +
+		"*.com\n" +
+		"foo..example.com\n" +
+		"intranet.local\n" +
+		"*.dev.example.com\n" +
+		"*.example.com\n" +
+		"amzn*.example.com\n" +
+		"*.*.co.uk\n" +
+		"10.0.0.0/8\n" +
+		"192.168.0.0/24\n" +
+		"*.foo.github.io\n" +
+		"https://example.com:99999\n"
+	checkForErrors(t, os.WriteFile(path, []byte(scopes), 0600))
+
+	issues, err := lintFiles([]string{path})
+	checkForErrors(t, err)
+
+	var found []string
+	for _, issue := range issues {
+		found = append(found, issue.Scope+" "+issue.Check)
+		equals(t, path, issue.File)
+	}
+	equals(t, []string{
+		"*.com too-broad",
+		"foo..example.com invalid",
+		"intranet.local no-public-suffix",
+		"*.dev.example.com redundant",
+		"*.example.com duplicate",
+		"*.*.co.uk too-broad",
+		"10.0.0.0/8 too-broad",
+		"https://example.com:99999 invalid",
+	}, found)
+	equals(t, 3, issues[0].Line)
+	equals(t, 13, issues[len(issues)-1].Line)
+	equals(t, "error", issues[len(issues)-1].Severity)
+
+	setGlobal(t, &outputFormat, "jsonl")
+	equals(t, exitLintIssues, exitCode(runLint([]string{path})))
+
+	checkForErrors(t, os.WriteFile(path, []byte("*.example.com\nexample.org\n"), 0600))
+	checkForErrors(t, runLint([]string{path}))
+
+	equals(t, exitUsage, exitCode(runLint([]string{filepath.Join(t.TempDir(), "missing")})))
+}
+
+func Test_lintFilesIncludes(t *testing.T) {
+	dir := t.TempDir()
+	inscope := filepath.Join(dir, ".inscope")
+	noscope := filepath.Join(dir, ".noscope")
+	sub := filepath.Join(dir, "sub.scope")
+	loop := filepath.Join(dir, "loop.scope")
+	checkForErrors(t, os.WriteFile(inscope, []byte("*.example.com\n@include sub.scope\n@include loop.scope\n"), 0600))
+	checkForErrors(t, os.WriteFile(sub, []byte("example.org\nbad host!!\n"), 0600))
+	checkForErrors(t, os.WriteFile(loop, []byte("@include .inscope\n"), 0600))
+	checkForErrors(t, os.WriteFile(noscope, []byte("example.org\n"), 0600))
+
+	issues, err := lintFiles([]string{inscope, noscope})
+	checkForErrors(t, err)
+
+	var found []string
+	for _, issue := range issues {
+		found = append(found, filepath.Base(issue.File)+":"+strconv.Itoa(issue.Line)+" "+issue.Check)
+	}
+	equals(t, []string{
+		// the included files are linted too
+		"sub.scope:1 contradiction",
+		"sub.scope:2 invalid",
+		"loop.scope:1 invalid",
+	}, found)
+	equals(t, true, strings.Contains(issues[0].Message, noscope+":1"))
+	equals(t, true, strings.Contains(issues[2].Message, "Include cycle detected"))
+
+	// a file reached through different paths is only linted once
+	workingDirectory, err := os.Getwd()
+	checkForErrors(t, err)
+	checkForErrors(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(workingDirectory) })
+	issues, err = lintFiles([]string{".inscope", sub})
+	checkForErrors(t, err)
+	equals(t, 2, len(issues))
+	equals(t, "sub.scope", issues[0].File)
+}
+
+func Test_hasPublicSuffix(t *testing.T) {
+	equals(t, true, hasPublicSuffix("example.com"))
+	equals(t, true, hasPublicSuffix("example.co.uk"))
+	equals(t, true, hasPublicSuffix("foo.github.io"))
+	equals(t, false, hasPublicSuffix("intranet.local"))
+	equals(t, false, hasPublicSuffix("localhost"))
+}
@@ -2,24 +2,23 @@ package main
 
 import (
 	"bufio"
-	"bytes"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
 	"golang.org/x/net/publicsuffix"
 )
 
@@ -31,7 +30,7 @@ var firebountyJSONPath string
 // https://tutorialedge.net/golang/parsing-json-with-golang/
 type Scope struct {
 	Scope      string //either a domain, or a wildcard domain
-	Scope_type string //we only care about "web_application"
+	Scope_type string //see scopeAssetTypes
 }
 
 type Program struct {
@@ -44,6 +43,10 @@ type Program struct {
 	Tag  string
 	Url  string //url.URL not allowed appearently
 	Name string
+
+	//where the program was loaded from, such as "firebounty" or "hackerone_data.json"
+	source       string
+	databasePath string
 }
 
 type WhiteLists struct {
@@ -59,12 +62,16 @@ type Firebounty struct {
 type firebountySearchMatch struct {
 	companyIndex int
 	companyName  string
+	// score is how well the company matched the search, from 1 to 100. field is the field of the program that matched best, such as "name" or "slug"
+	score int
+	field string
 }
 
 var chainMode bool
 var targetsListFilepath string
 var targetsListFile *os.File
 var includeUnsure bool
+var includeWhitelisted bool
 
 const colorReset = "\033[0m"
 const colorYellow = "\033[33m"
@@ -77,22 +84,38 @@ var inscopeOutputFile string
 var inscopeURLs []string
 var unsureURLs []string
 var outputDomainsOnly bool
+var outputFormat string
+var explainMode bool
+var targetAsset scope.AssetType
+var results *resultWriter
+var foundInScope bool
 
 func main() {
+	err := run()
+	if err != nil && !errors.Is(err, errNoneInScope) {
+		printError(err)
+	}
+	os.Exit(exitCode(err))
+}
+
+func run() error {
 
 	var version string
 	var showVersion bool
 	var company string
-	var explicitLevel int //should only be [0], 1, or 2
-	var scopesListFilepath string
-	var outofScopesListFilepath string
+	var explicitLevel int //should only be [1], 2, or 3
+	var scopesListFilepaths stringList
+	var outofScopesListFilepaths stringList
+	var policyName string
+	var targetTypeName string
+	var nameRegex string
 	usedstdin = false
 
 	version = "v4.0.0"
 
 	const usage = `Hacker-scoper is a Go (v1.17.2) tool designed to assist cybersecurity professionals in bug bounty programs. It identifies and excludes URLs and IP addresses that fall outside a program's scope by comparing input targets (URLs/IPs) against a locally cached [FireBounty](https://firebounty.com) database of scraped scope data. Users may also supply a custom scope list for validation.
 
-` + colorBlue + `Usage:` + colorReset + ` hacker-scoper --file /path/to/targets [--company company | --custom-inscopes-file /path/to/inscopes [--custom-outofcopes-file /path/to/outofscopes]] [--explicit-level INT] [--reuse Y/N] [--chain-mode] [--database /path/to/firebounty.json] [--include-unsure] [--output /path/to/outputfile] [--hostnames-only]
+` + colorBlue + `Usage:` + colorReset + ` hacker-scoper --file /path/to/targets [--company company [--platform firebounty|hackerone|bugcrowd|intigriti|yeswehack] [--exact-name | --name-regex regex] [--program-slug slug] [--program-url url] [--program-index INT | --all-matches] | --custom-inscopes-file /path/to/inscopes [--custom-outofcopes-file /path/to/outofscopes]] [--explicit-level INT] [--reuse Y/N] [--chain-mode] [--database /path/to/database/folder [--database-format firebounty|bounty-targets-data]] [--include-whitelisted] [--include-unsure] [--output /path/to/outputfile] [--hostnames-only] [--format text|json|jsonl] [--source kind:value]... [--policy exclude-wins|most-specific-wins|include-wins] [--target-type web|android|ios|repository|executable]
 
 ` + colorBlue + `Usage examples:` + colorReset + `
   Example: Cat a file, and lookup scopes on firebounty
@@ -107,21 +130,91 @@ func main() {
   Example: Manually pick a file, use custom scopes and out-of-scope files, and set explicit-level
   ` + colorGreen + `hacker-scoper -f recon-targets.txt -ins inscope -oos noscope.txt -e 2 ` + colorReset + `
 
+  Example: Lookup scopes on firebounty without being asked to pick a program, such as in a CI job
+  ` + colorGreen + `hacker-scoper -f recon-targets.txt -c google --exact-name --program-index 0 -ch` + colorReset + `
+
+  Example: Lookup the scopes of a private HackerOne program
+  ` + colorGreen + `HACKERONE_USERNAME=user HACKERONE_API_TOKEN=token hacker-scoper -f recon-targets.txt --hackerone -c security` + colorReset + `
+
+  Example: Lookup the current scopes of a Bugcrowd program
+  ` + colorGreen + `BUGCROWD_TOKEN=token hacker-scoper -f recon-targets.txt -c tesla --platform bugcrowd` + colorReset + `
+
+  Example: Lookup the current scopes of an Intigriti program
+  ` + colorGreen + `INTIGRITI_TOKEN=token hacker-scoper -f recon-targets.txt -c intigriti --platform intigriti` + colorReset + `
+
+  Example: Lookup scopes on an offline copy of the bounty-targets-data files
+  ` + colorGreen + `hacker-scoper -f recon-targets.txt -c tesla --database bounty-targets-data/data/ --database-format bounty-targets-data` + colorReset + `
+
+  Example: Combine the public scopes of a company with the scopes of a private HackerOne program and your own scopes file
+  ` + colorGreen + `hacker-scoper -f recon-targets.txt --source company:google --source hackerone:google --source inscope-file:my-scopes.txt` + colorReset + `
+
+  Example: Explain why a target is (or isn't) in scope
+  ` + colorGreen + `hacker-scoper explain -c google https://admin.google.com/login` + colorReset + `
+
+  Example: Check the scope files for mistakes, and get a machine-readable report
+  ` + colorGreen + `hacker-scoper lint --format json .inscope .noscope` + colorReset + `
+
+  Example: Check which Android packages of a list are in the scope of a company
+  ` + colorGreen + `hacker-scoper -f apk-packages.txt -c google --target-type android` + colorReset + `
+
+  Example: Find which bug bounty programs own an asset found during recon
+  ` + colorGreen + `hacker-scoper whois-program sub.example.com 203.0.113.7` + colorReset + `
+
+  Example: Find the owners of every target of a file, as one JSON object per target
+  ` + colorGreen + `cat recon-targets.txt | hacker-scoper whois-program --format jsonl` + colorReset + `
+
+  Example: Output one JSON object per target, and keep only the out-of-scope ones with jq
+  ` + colorGreen + `cat recon-targets.txt | hacker-scoper -c google --format jsonl | jq 'select(.verdict == "out")'` + colorReset + `
+
 ` + colorBlue + `Usage notes:` + colorReset + `
-  If no company and no inscope file is specified, hacker-scoper will look for ".inscope" and ".noscope" files in the current or in parent directories.
+  If no company, no inscope file and no source is specified, hacker-scoper will look for ".inscope" and ".noscope" files in the current or in parent directories.
+  In scope files, blank lines and "#" comments are ignored, "key=value" pairs in a comment are saved as annotations of the scope, and lines starting with "!" mean the opposite of the rest of the file (e.g. "!admin.example.com" in a .inscope file is out of scope). "@include <path>" loads the scopes of another file, or of every file matched by a glob. The path is relative to the file that includes it.
+  "hacker-scoper lint [--format text|json|jsonl] file..." (or "validate") checks every line of the scope files without classifying any target, and reports invalid hosts, hosts without a public suffix, overly broad wildcards and networks (such as "*.com" or "10.0.0.0/8"), and duplicated, redundant or contradicting scopes. The included files are checked too, and the scopes of every file are compared with each other: the files whose name ends with "noscope" are out-of-scope lists. Without files, the ".inscope" and ".noscope" files are checked.
+  The company is searched in the name, slug, tag and domain of every program of the database, ignoring case and accents, and tolerating typos. If several programs of the database match the company (or only a few with typos), the user is asked to pick one of them, or all of them, from a list ranked by score (100 for an exact match, 90 for a prefix, 80 for the start of a word, 70 for any substring, and 60 or less for typos). Without a terminal, or in chain-mode, the programs must be picked with --program-slug, --program-url, --exact-name, --name-regex, --program-index or --all-matches. Otherwise, hacker-scoper exits with the code 2 and lists the matching programs.
+  Besides web applications, the Android apps, iOS apps, source code repositories and executables of the programs are used as scopes. They're only matched against targets of the same --target-type. The scopes of other types (such as "hardware" or "other") are skipped with a warning.
+  "hacker-scoper whois-program [arguments] target..." classifies every target with the scopes of every program of the database (see --database-format), and lists the programs that own it (or that explicitly put it out of scope) with their Firebounty URL and the rule that matched. Without targets, they're read from --file or from stdin. In chain-mode, a "target<TAB>program<TAB>url" line is output for every program that owns a target.
+
+` + colorBlue + `Exit codes:` + colorReset + `
+  0: At least one target is in scope
+  1: None of the targets are in scope
+  2: Usage error (invalid arguments, unknown or ambiguous company, missing scope files)
+  3: Database error (the firebounty database or the scopes of a platform couldn't be downloaded, read or parsed)
+  4: I/O error (a targets, scopes or output file couldn't be read or written)
+  5: The lint subcommand found problems in the scope files
 
 ` + colorBlue + `List of all possible arguments:` + colorReset + `
   -c, --company string
       Specify the company name to lookup.
 
+  --exact-name
+      Only match the programs whose name is exactly the company (ignoring case and accents), instead of searching it.
+
+  --name-regex string
+      Only match the programs whose name matches this regex, instead of searching the company. The company may be omitted.
+
+  --program-slug string
+      Only match the program of the database with this slug (e.g. "tesla"). The company may be omitted.
+
+  --program-url string
+      Only match the program of the database with this URL or firebounty URL. The company may be omitted.
+
+  --program-index int
+      If several programs matched, pick the one with this index. The indexes are the ones listed when being asked to pick a program.
+
+  --all-matches
+      If several programs matched, combine all of them as if they were a single company. Same as picking "COMBINE ALL" when being asked to pick a program.
+
   -f, --file string
-      Path to your file containing URLs
+      Path to your file containing URLs. The targets may also be given as arguments, after the flags.
 
   -ins, --inscope-file string
-      Path to a custom plaintext file containing scopes
+      Path to a custom plaintext file containing scopes. May be repeated, or be a glob such as "scopes/*.txt"
 
   -oos, --outofcope-file string
-      Path to a custom plaintext file containing scopes exclusions
+      Path to a custom plaintext file containing scopes exclusions. May be repeated, or be a glob such as "exclusions/*.txt"
+
+  --merge-parent-scopes
+      Merge every .inscope and .noscope file found in the current directory and in its parents (up to the filesystem root), instead of using only the nearest ones. Useful to keep an organization-wide .noscope in a parent directory, and a .inscope per engagement.
 
   -e, --explicit-level int
       How explicit we expect the scopes to be:
@@ -140,6 +233,12 @@ func main() {
 		- Linux: /etc/hacker-scoper/
 		- Android: $HOME/.hacker-scoper/
 
+  --database-format string
+      Format of the database: firebounty (default) or bounty-targets-data. With bounty-targets-data, the programs of every hackerone_data.json, bugcrowd_data.json, intigriti_data.json, yeswehack_data.json and federacy_data.json file in the --database folder are searched with --company. These files are never downloaded nor updated, so a vetted snapshot can be used offline.
+
+  --include-whitelisted
+      Merge the "White_listed" domains of the firebounty database into the in-scopes of their program. Some programs have whitelisted domains that are missing from their in-scopes. The whitelisted rules are tagged with the "firebounty:white_listed" source, which is shown by --explain and the JSON formats. Also used by whois-program.
+
   -iu, --include-unsure
       Include "unsure" URLs in the output. An unsure URL is a URL that's not in scope, but is also not out of scope. Very probably unrelated to the bug bounty program.
 
@@ -149,6 +248,63 @@ func main() {
   -ho, --hostnames-only
       Output only hostnames instead of the full URLs
 
+  --format string
+      Output format: text (default), json or jsonl. The JSON formats output one object per target, with its verdict (in/out/unsure/unparseable) and the rule that decided it. Implies chain-mode.
+
+  --policy string
+      How to classify a target matched by both an in-scope and an out-of-scope rule:
+        - exclude-wins (default): the target is out of scope
+        - most-specific-wins: the most specific rule wins, such as "api.example.com" over "*.example.com", or "10.0.0.5" over "10.0.0.0/24". Ties are out of scope
+        - include-wins: the target is in scope
+      Unless in chain-mode, the contradicting (listed both as in scope and as out of scope), shadowed (never used because of the policy) and redundant rules are reported as warnings when the scopes are loaded.
+
+  --explain
+      Print the evaluation trace of every target instead of classifying them: every rule that was tried, whether it matched, and the rule that decided the verdict. "hacker-scoper explain [arguments] target..." does the same for the targets given as arguments. Only the text format is supported.
+
+  --platform string
+      Where to lookup the scopes of the company: firebounty (default), hackerone, bugcrowd, intigriti or yeswehack. The platforms give the current scopes of public and private programs, and the company must be the program handle (e.g. "tesla" for https://bugcrowd.com/tesla). The scopes are cached next to the firebounty database for 24hs.
+      The API credentials are read from these environment variables, or from the "credentials.json" file next to the firebounty database:
+        - hackerone: HACKERONE_USERNAME and HACKERONE_API_TOKEN
+        - bugcrowd: BUGCROWD_TOKEN (an API key in the "username:password" form, or the value of the "_bugcrowd_session" cookie)
+        - intigriti: INTIGRITI_TOKEN (a researcher API token)
+        - yeswehack: YESWEHACK_TOKEN (a personal access token)
+
+  --hackerone
+      Same as "--platform hackerone".
+
+  --hackerone-api-url string
+      Base URL of the HackerOne API.
+	  	Default: ` + hackeroneDefaultAPIURL + `
+
+  --bugcrowd-url string
+      Base URL of Bugcrowd.
+	  	Default: ` + bugcrowdDefaultURL + `
+
+  --intigriti-api-url string
+      Base URL of the Intigriti researcher API.
+	  	Default: ` + intigritiDefaultAPIURL + `
+
+  --yeswehack-api-url string
+      Base URL of the YesWeHack API.
+	  	Default: ` + yeswehackDefaultAPIURL + `
+
+  --target-type string
+      Type of the targets: web (default), android, ios, repository or executable. The targets are only matched against the scopes of the same type:
+        - web: URLs, hostnames and IP addresses
+        - android: Android package names ("com.example.app") or Google Play URLs
+        - ios: iOS bundle IDs ("com.example.app") or App Store URLs
+        - repository: repository URLs ("https://github.com/example/app" or "git@github.com:example/app.git")
+        - executable: file names or download URLs of executables ("ExampleSetup.exe")
+      The JSON formats output the "type" and the normalized "identifier" of the non-web targets.
+
+  --source kind:value
+      Load scopes from a source, and combine them with the scopes of every other source. May be repeated. Every rule remembers the source it came from, which is shown by --explain and the JSON formats. The sources are:
+        - company:<name>: the companies of the database that match the search of <name>
+        - hackerone:<handle>, bugcrowd:<handle>, intigriti:<handle>, yeswehack:<handle>: a program of a platform (see --platform)
+        - inscope-file:<path>, outofscope-file:<path>: custom plaintext files containing scopes or scopes exclusions. The path may be a glob
+        - url:<url>: a plaintext list of scopes downloaded over http(s)
+        - .inscope: the ".inscope" and ".noscope" files of the current or parent directories
+
   --version
       Show the installed version
 
@@ -158,25 +314,63 @@ func main() {
 	flag.StringVar(&company, "company", "", "Specify the company name to lookup.")
 	flag.StringVar(&targetsListFilepath, "f", "", "Path to your file containing URLs")
 	flag.StringVar(&targetsListFilepath, "file", "", "Path to your file containing URLs")
-	flag.StringVar(&scopesListFilepath, "ins", "", "Path to a custom plaintext file containing scopes")
-	flag.StringVar(&scopesListFilepath, "inscope-file", "", "Path to a custom plaintext file containing scopes")
-	flag.StringVar(&outofScopesListFilepath, "oos", "", "Path to a custom plaintext file containing scopes exclusions")
-	flag.StringVar(&outofScopesListFilepath, "outofcope-file", "", "Path to a custom plaintext file containing scopes exclusions")
+	flag.Var(&scopesListFilepaths, "ins", "Path or glob of custom plaintext files containing scopes. May be repeated")
+	flag.Var(&scopesListFilepaths, "inscope-file", "Path or glob of custom plaintext files containing scopes. May be repeated")
+	flag.Var(&outofScopesListFilepaths, "oos", "Path or glob of custom plaintext files containing scopes exclusions. May be repeated")
+	flag.Var(&outofScopesListFilepaths, "outofcope-file", "Path or glob of custom plaintext files containing scopes exclusions. May be repeated")
+	flag.BoolVar(&mergeParentScopes, "merge-parent-scopes", false, "Merge every .inscope and .noscope file found in the current and parent directories, instead of using only the nearest ones")
 	flag.IntVar(&explicitLevel, "e", 1, "Level of explicity expected. ([1]/2/3)")
 	flag.IntVar(&explicitLevel, "explicit-level", 1, "Level of explicity expected. ([1]/2/3)")
 	flag.BoolVar(&chainMode, "ch", false, "In \"chain-mode\" we only output the important information. No decorations.")
 	flag.BoolVar(&chainMode, "chain-mode", false, "In \"chain-mode\" we only output the important information. No decorations.")
 	flag.StringVar(&firebountyJSONPath, "database", "", "Custom path to the cached firebounty database")
+	flag.StringVar(&databaseFormat, "database-format", "firebounty", "Format of the database: firebounty or bounty-targets-data")
 	flag.StringVar(&inscopeOutputFile, "o", "", "Save the inscope urls to a file")
 	flag.StringVar(&inscopeOutputFile, "output", "", "Save the inscope urls to a file")
 	flag.BoolVar(&showVersion, "version", false, "Show installed version")
+	flag.BoolVar(&includeWhitelisted, "include-whitelisted", false, "Merge the \"White_listed\" domains of the firebounty database into the in-scopes of their program")
 	flag.BoolVar(&includeUnsure, "iu", false, "Include \"unsure\" URLs in the output. An unsure URL is a URL that's not in scope, but is also not out of scope. Very probably unrelated to the bug bounty program.")
 	flag.BoolVar(&includeUnsure, "include-unsure", false, "Include \"unsure\" URLs in the output. An unsure URL is a URL that's not in scope, but is also not out of scope. Very probably unrelated to the bug bounty program.")
 	flag.BoolVar(&outputDomainsOnly, "ho", false, "Output only domains instead of the full URLs")
 	flag.BoolVar(&outputDomainsOnly, "hostnames-only", false, "Output only domains instead of the full URLs")
+	flag.StringVar(&outputFormat, "format", "text", "Output format: text, json or jsonl")
 	//https://www.antoniojgutierrez.com/posts/2021-05-14-short-and-long-options-in-go-flags-pkg/
+	flag.BoolVar(&explainMode, "explain", false, "Print the evaluation trace of every target, instead of classifying them")
+	flag.StringVar(&platform, "platform", "firebounty", "Where to lookup the scopes of the company: firebounty, hackerone, bugcrowd, intigriti or yeswehack")
+	flag.BoolVar(&hackeroneMode, "hackerone", false, "Same as \"--platform hackerone\"")
+	flag.StringVar(&hackeroneAPIURL, "hackerone-api-url", hackeroneDefaultAPIURL, "Base URL of the HackerOne API")
+	flag.StringVar(&bugcrowdURL, "bugcrowd-url", bugcrowdDefaultURL, "Base URL of Bugcrowd")
+	flag.StringVar(&intigritiAPIURL, "intigriti-api-url", intigritiDefaultAPIURL, "Base URL of the Intigriti researcher API")
+	flag.StringVar(&yeswehackAPIURL, "yeswehack-api-url", yeswehackDefaultAPIURL, "Base URL of the YesWeHack API")
+	flag.StringVar(&policyName, "policy", "exclude-wins", "How to classify targets matched by both an in-scope and an out-of-scope rule: exclude-wins, most-specific-wins or include-wins")
+	flag.StringVar(&selection.slug, "program-slug", "", "Pick the program of the database with this slug")
+	flag.StringVar(&selection.url, "program-url", "", "Pick the program of the database with this URL or firebounty URL")
+	flag.BoolVar(&selection.exactName, "exact-name", false, "Pick the programs whose name is exactly the company, instead of searching it")
+	flag.StringVar(&nameRegex, "name-regex", "", "Pick the programs whose name matches this regex, instead of searching the company")
+	flag.IntVar(&selection.index, "program-index", -1, "Pick a single program out of the programs that matched the company, by its index")
+	flag.BoolVar(&selection.allMatches, "all-matches", false, "Combine every program that matched the company, as if they were a single company")
+	flag.StringVar(&targetTypeName, "target-type", "web", "Type of the targets: web, android, ios, repository or executable")
+	flag.Var(&sourceSpecs, "source", "Load scopes from a source (kind:value). May be repeated to combine several sources")
 	flag.Usage = func() { fmt.Print(usage) }
-	flag.Parse()
+
+	//"hacker-scoper explain [arguments] target..." is the same as "hacker-scoper --explain [arguments] target..."
+	args := os.Args[1:]
+	//"hacker-scoper lint [arguments] file..." checks scope files instead of classifying targets. "validate" is an alias.
+	//"hacker-scoper whois-program [arguments] target..." finds the programs of the database that own the targets
+	if len(args) > 0 && args[0] == "explain" {
+		explainMode = true
+		args = args[1:]
+	} else if len(args) > 0 && (args[0] == "lint" || args[0] == "validate") {
+		lintMode = true
+		args = args[1:]
+	} else if len(args) > 0 && args[0] == "whois-program" {
+		whoisMode = true
+		args = args[1:]
+	}
+	_ = flag.CommandLine.Parse(args) // #nosec G104 -- flag.CommandLine exits on errors.
+
+	//targets may be given as arguments in explain mode
+	explainTargets := flag.Args()
 
 	banner := `
 '||                      '||                      '                                                 
@@ -190,7 +384,11 @@ func main() {
 
 	if showVersion {
 		fmt.Print("hacker-scoper:" + version + "\n")
-		os.Exit(0)
+		return nil
+	}
+
+	if lintMode {
+		return runLint(flag.Args())
 	}
 
 	if firebountyJSONPath == "" {
@@ -220,62 +418,81 @@ func main() {
 				//Create the folder
 				err := os.Mkdir(firebountyJSONPath, 0600)
 				if err != nil {
-					crash("Unable to create the folder \""+firebountyJSONPath+"\"", err)
+					return &databaseError{"Unable to create the folder \"" + firebountyJSONPath + "\"", err}
 				}
 			} else if err != nil {
 				// Schrodinger: file may or may not exist. See err for details.
-				crash("Could not verify existance of the folder \""+firebountyJSONPath+"\"!", err)
+				return &databaseError{"Could not verify existance of the folder \"" + firebountyJSONPath + "\"!", err}
 			}
 		}
 	}
 
+	//the bounty-targets-data files are read from the database folder
+	databaseFolder := firebountyJSONPath
 	firebountyJSONPath = firebountyJSONPath + firebountyJSONFilename
 
+	//validate arguments
+	if outputFormat != "text" && outputFormat != "json" && outputFormat != "jsonl" {
+		return &usageError{"Invalid output format selected: " + outputFormat, nil}
+	}
+	if explainMode && outputFormat != "text" {
+		//the traces are only printed as text
+		return &usageError{"--explain can't be used with --format " + outputFormat, nil}
+	}
+	if outputFormat != "text" {
+		//decorations would break the JSON output
+		chainMode = true
+	}
+
 	if !chainMode {
 		fmt.Println(banner)
 	}
 
 	//validate arguments
 	if (explicitLevel != 1) && (explicitLevel != 2) && explicitLevel != 3 {
-		var err error
-		crash("Invalid explicit-level selected", err)
+		return &usageError{"Invalid explicit-level selected: " + strconv.Itoa(explicitLevel), nil}
+	}
+	if databaseFormat != "firebounty" && databaseFormat != "bounty-targets-data" {
+		return &usageError{"Invalid database format selected: " + databaseFormat + ". Use either firebounty or bounty-targets-data", nil}
+	}
+	policy, err := scope.ParsePolicy(policyName)
+	if err != nil {
+		return &usageError{"Invalid policy selected: " + policyName + ". Use one of: exclude-wins, most-specific-wins, include-wins", nil}
+	}
+	targetAsset, err = scope.ParseAssetType(targetTypeName)
+	if err != nil {
+		return &usageError{"Invalid target type selected: " + targetTypeName + ". Use one of: web, android, ios, repository, executable", nil}
+	}
+	if nameRegex != "" {
+		selection.nameRegex, err = regexp.Compile(nameRegex)
+		if err != nil {
+			return &usageError{"Invalid name regex: " + nameRegex, err}
+		}
+	}
+	if selection.allMatches && selection.index >= 0 {
+		return &usageError{"--all-matches and --program-index can't be used at the same time", nil}
+	}
+	if hackeroneMode {
+		platform = "hackerone"
+	}
+	if !slices.Contains(platforms, platform) {
+		return &usageError{"Invalid platform selected: " + platform + ". Use one of: " + strings.Join(platforms, ", "), nil}
+	}
+	if platform != "firebounty" && company == "" {
+		return &usageError{"The " + platform + " platform requires a program handle. Specify it with --company", nil}
 	}
 
 	// If we're getting input from stdin...
 	//https://stackoverflow.com/a/26567513/11490425
 	stat, _ := os.Stdin.Stat()
-	if (stat.Mode()&os.ModeCharDevice) == 0 && !isVSCodeDebug() {
-
-		var stdinInput string
-
-		//read stdin
-		scanner := bufio.NewScanner(os.Stdin)
-		for scanner.Scan() {
-			stdinInput += "\n" + scanner.Text()
-		}
-		if err := scanner.Err(); err != nil {
-			crash("bufio couldn't read stdin correctly.", err)
-		}
-
-		// Write to disk in a securely-generated temporary file (CWE-377)
-		//os.CreateTemp(dir, pattern string) (*File, error)
-		secureTempFile, err := os.CreateTemp("", "hacker-scoper_stdin-scopes-tmp-file*.txt")
-		if err != nil {
-			crash("Couldn't create tmp file for storing stdin.", err)
-		}
-		err = os.WriteFile(secureTempFile.Name(), []byte(stdinInput), 0600)
-		if err != nil {
-			crash("Couldn't save write to tmp file.", err)
-		}
-
-		_, err = popLine(secureTempFile)
-		if err != nil {
-			crash("An unknown error ocurred while reading the temporary file for processing the stdin input.", err)
-		}
+	if len(explainTargets) > 0 {
+		//the targets were given as arguments, so there's no file to read
+		targetsListFilepath = ""
+	} else if (stat.Mode()&os.ModeCharDevice) == 0 && !isVSCodeDebug() {
 
+		//stdin is streamed straight into the matcher, so there's no need to store it anywhere
 		usedstdin = true
-
-		targetsListFile = secureTempFile
+		targetsListFile = os.Stdin
 
 	} else {
 		// We didn't get anything from stdin, so we will use the file specified by the user
@@ -288,220 +505,79 @@ func main() {
 		var err error
 		targetsListFile, err = os.Open(targetsListFilepath) // #nosec G304 -- targetsListFilepath is a CLI argument specified by the user running the program. It is not unsafe to allow them to open any file in their own system.
 		if err != nil {
-			crash("Could not open your provided URL list file", err)
+			return &ioError{"Could not open your provided URL list file", err}
 		}
 
 	}
 
-	if company == "" && scopesListFilepath == "" {
-		//var err error
-		//crash("A company name is required to smartly weed-out out-of-scope URLs", err)
-
-		if !chainMode {
-			fmt.Print("No company or scopes file specified. Looking for a \".inscope\" file..." + "\n")
-		}
-
-		//look for .inscope file
-		inscopePath, err := searchForFileBackwards(".inscope")
+	if whoisMode {
+		database, err := loadDatabase(databaseFolder)
 		if err != nil {
-			crash("Couldn't locate a .inscope file.", err)
+			return err
 		}
+		return runWhois(explainTargets, targetsListFile, database, scope.Options{ExplicitLevel: explicitLevel, Policy: policy})
+	}
 
-		if !chainMode {
-			fmt.Print(".inscope found. Using " + inscopePath + "\n")
-		}
+	sources, err := scopeSources(sourceSpecs, company, scopesListFilepaths, outofScopesListFilepaths, databaseFolder)
+	if err != nil {
+		return err
+	}
+	includes, excludes, err := loadSources(sources)
+	if err != nil {
+		return err
+	}
 
-		//look for .inscope file
-		noscopePath, err := searchForFileBackwards(".noscope")
-		if err != nil {
-			noscopePath = ""
-		} else if !chainMode {
-			fmt.Print(".noscope found. Using " + noscopePath + "\n")
-		}
+	matcher, err := scope.NewMatcher(includes, excludes, scope.Options{ExplicitLevel: explicitLevel, Policy: policy})
+	if err != nil {
+		return &usageError{"Couldn't compile the scopes", err}
+	}
+	if !chainMode {
+		printConflicts(matcher.Conflicts())
+	}
 
-		inscopeFileio, err := os.Open(inscopePath) // #nosec G304 -- inscopePath is a CLI argument specified by the user running the program. It is not unsafe to allow them to open any file in their own system.
+	//JSON results are written while the targets are being read
+	if outputFormat != "text" {
+		results, err = newResultWriter(outputFormat, inscopeOutputFile)
 		if err != nil {
-			crash("Couldn't open "+inscopePath, err)
+			return &ioError{"Unable to open the output file", err}
 		}
+	}
 
-		//Read the file line per line using bufio
-		scopesScanner := bufio.NewScanner(inscopeFileio)
-
-		for scopesScanner.Scan() {
-			parseScopesWrapper(scopesScanner.Text(), explicitLevel, targetsListFile, noscopePath, nil)
+	if len(explainTargets) > 0 {
+		//the targets given as arguments are output just like the targets of a file
+		for _, target := range explainTargets {
+			if err := classifyTarget(matcher, target); err != nil {
+				return err
+			}
 		}
-		err = inscopeFileio.Close()
-		if err != nil {
-			crash("Couldn't close '"+inscopePath+"'. The file was already closed.", err)
+	} else {
+		//Read the URLs file line per line
+		targetsScanner := bufio.NewScanner(targetsListFile)
+		for targetsScanner.Scan() {
+			//blank lines aren't targets, so they're not reported as unparseable
+			if strings.TrimSpace(targetsScanner.Text()) == "" {
+				continue
+			}
+			if err := classifyTarget(matcher, targetsScanner.Text()); err != nil {
+				return err
+			}
+		}
+		if err := targetsScanner.Err(); err != nil {
+			return &ioError{"Could not read URL List file successfully", err}
 		}
 
 		err = targetsListFile.Close()
 		if err != nil {
-			crash("Couldn't close '"+targetsListFilepath+"'. The file was already closed.", err)
+			return &ioError{"Couldn't close '" + targetsListFilepath + "'. The file was already closed", err}
 		}
+	}
 
-	} else {
-
-		//user selected a company. Use the firebounty db
-		if company != "" {
-			if firebountyJSONFileStats, err := os.Stat(firebountyJSONPath); err == nil {
-				// path/to/whatever exists
-				//check age. if age > 24hs
-				yesterday := time.Now().Add(-24 * time.Hour)
-				if firebountyJSONFileStats.ModTime().Before(yesterday) {
-					if !chainMode {
-						fmt.Println("[INFO]: +24hs have passed since the last update to the local firebounty database. Updating...")
-					}
-					updateFireBountyJSON()
-				}
-
-			} else if errors.Is(err, os.ErrNotExist) {
-				//path/to/whatever does not exist
-				if !chainMode {
-					fmt.Println("[INFO]: Downloading scopes file and saving in \"" + firebountyJSONPath + "\"")
-				}
-
-				updateFireBountyJSON()
-
-			} else {
-				// Schrodinger: file may or may not exist. See err for details.
-				panic(err)
-			}
-
-			//open json
-			jsonFile, err := os.Open(firebountyJSONPath) // #nosec G304 -- firebountyJSONPath is a CLI argument specified by the user running the program. It is not unsafe to allow them to open any file in their own system.
-			if err != nil {
-				crash("Couldn't open firebounty JSON. Maybe run \"chmod 777 "+firebountyJSONPath+"\"? ", err)
-			}
-
-			//read the json file as bytes
-			byteValue, _ := io.ReadAll(jsonFile)
-			jsonFile.Close() // #nosec G104 -- No need to worry about double-closing issues, as the file is closed right after reading it.
-
-			var firebountyJSON Firebounty
-			err = json.Unmarshal(byteValue, &firebountyJSON)
-			if err != nil {
-				crash("Couldn't parse firebountyJSON into pre-defined struct.", err)
-			}
-
-			var matchingCompanyList []firebountySearchMatch
-			var userChoice string
-			var userPickedInvalidChoice bool = true
-			var userChoiceAsInt int
-
-			//for every company...
-			for companyCounter := 0; companyCounter < len(firebountyJSON.Pgms); companyCounter++ {
-				fcompany := strings.ToLower(firebountyJSON.Pgms[companyCounter].Name)
-				if strings.Contains(fcompany, company) {
-					matchingCompanyList = append(matchingCompanyList, firebountySearchMatch{companyCounter, firebountyJSON.Pgms[companyCounter].Name})
-				}
-			}
-			if len(matchingCompanyList) == 0 && !chainMode {
-				fmt.Println(string(colorRed) + "[-] 0 (lowercase'd) company names contained the string \"" + company + "\"" + string(colorReset))
-				fmt.Println(string(colorRed) + "[-] Consider either of these options:")
-				fmt.Println(string(colorRed) + "\t - Doing a manual search at https://firebounty.com")
-				fmt.Println(string(colorRed) + "\t - Loading the scopes manually into '.inscope' and '.noscope' files.")
-				fmt.Println(string(colorRed) + "\t - Loading the scopes manually into custom files, specified with the --inscope-file and --outofscope-file arguments.")
-			} else if len(matchingCompanyList) > 1 {
-
-				if chainMode {
-					err = nil
-					crash("Unable to match the company to a single company. Please use a more exact company string.", err)
-				}
-
-				//appearently "while" doesn't exist in Go. It has been replaced by "for"
-				for userPickedInvalidChoice {
-					//For every matchingCompanyList item...
-					for i := 0; i < len(matchingCompanyList)-1; i++ {
-						//Print it
-						fmt.Println("    " + strconv.Itoa(i) + " - " + matchingCompanyList[i].companyName)
-					}
-
-					//Show user the option to combine all of the previous companies as if they were a single company
-					fmt.Println("    " + strconv.Itoa(len(matchingCompanyList)) + " - COMBINE ALL")
-
-					//Get userchoice
-					fmt.Print("\n[+] Multiple companies matched \"" + company + "\". Please choose one: ")
-					_, err = fmt.Scanln(&userChoice)
-					if err != nil {
-						crash("An error ocurred while reading user input.", err)
-					}
-
-					//Convert userchoice str -> int
-					userChoiceAsInt, err = strconv.Atoi(userChoice)
-					//If the user picked something invalid...
-					if err != nil {
-						warning("Invalid option selected!")
-					} else {
-						userPickedInvalidChoice = false
-					}
-				}
-
-				//tip
-				fmt.Println("[-] If you want to remove one of these options, feel free to modify your firebounty database: " + firebountyJSONPath + "\n")
-
-				//If the user chose to "COMBINE ALL"...
-				if userChoiceAsInt == len(matchingCompanyList) {
-					//for every company that matched the company query...
-					for i := 0; i < len(matchingCompanyList); i++ {
-
-						//Load the matchingCompanyList 2D slice, and convert the first member from string to integer, and save the company index
-						companyIndex := matchingCompanyList[i].companyIndex
-						parseCompany(company, firebountyJSON, companyIndex, explicitLevel, outofScopesListFilepath)
-					}
-				} else {
-
-					//Use userChoiceAsInt as an index for the matchingCompanyList 2D slice, and save the company index
-					companyCounter := matchingCompanyList[userChoiceAsInt].companyIndex
-					parseCompany(company, firebountyJSON, companyCounter, explicitLevel, outofScopesListFilepath)
-				}
-
-			} else {
-				//Only 1 company matched the query
-				parseCompany(company, firebountyJSON, matchingCompanyList[0].companyIndex, explicitLevel, outofScopesListFilepath)
-			}
-
-			//user chose to use their own scope list
-		} else {
-
-			if _, err := os.Stat(scopesListFilepath); err == nil {
-				// path/to/whatever exists
-
-				//when using this custom scope, most likely there will be more targets than scopes, so we will nest scopes->targets for more efficiency
-
-				//open the file
-				//https://stackoverflow.com/a/16615559/11490425
-				scopesFile, err := os.Open(scopesListFilepath) // #nosec G304 -- scopesListFilepath is a CLI argument specified by the user running the program. It is not unsafe to allow them to open any file in their own system.
-				if err != nil {
-					crash("Could not open "+scopesListFilepath, err)
-				}
-
-				//Read the file line per line using bufio
-				scopesScanner := bufio.NewScanner(scopesFile)
-
-				for scopesScanner.Scan() {
-					parseScopesWrapper(scopesScanner.Text(), explicitLevel, targetsListFile, outofScopesListFilepath, nil)
-				}
-				err = scopesFile.Close()
-				if err != nil {
-					crash("Couldn't close '"+scopesListFilepath+"'. The file was already closed.", err)
-				}
-				err = targetsListFile.Close()
-				if err != nil {
-					crash("Couldn't close '"+scopesListFilepath+"'. The file was already closed.", err)
-				}
-
-			} else if errors.Is(err, os.ErrNotExist) {
-				//path/to/whatever does not exist
-				err = nil
-				crash(scopesListFilepath+" does not exist.", err)
-
-			} else {
-				// Schrodinger: file may or may not exist. See err for details.
-				panic(err)
-			}
+	if results != nil {
+		err = results.close()
+		if err != nil {
+			return &ioError{"Unable to write the results", err}
 		}
-
+		return inscopeResult()
 	}
 
 	inscopeURLs = removeDuplicateStr(inscopeURLs)
@@ -512,15 +588,17 @@ func main() {
 		sort.Strings(unsureURLs)
 
 		//If a URL is in inscopeURLs and unsureURLs, remove it from unsureURLs
-	unsureURLsloopstart:
-		for i := 0; i < len(unsureURLs); i++ {
-			for j := 0; j < len(inscopeURLs); j++ {
-				if unsureURLs[i] == inscopeURLs[j] {
-					unsureURLs = append(unsureURLs[:i], unsureURLs[i+1:]...)
-					goto unsureURLsloopstart
-				}
+		inscopeSet := make(map[string]bool, len(inscopeURLs))
+		for _, inscopeURL := range inscopeURLs {
+			inscopeSet[inscopeURL] = true
+		}
+		filteredUnsureURLs := unsureURLs[:0]
+		for _, unsureURL := range unsureURLs {
+			if !inscopeSet[unsureURL] {
+				filteredUnsureURLs = append(filteredUnsureURLs, unsureURL)
 			}
 		}
+		unsureURLs = filteredUnsureURLs
 
 	}
 
@@ -528,7 +606,7 @@ func main() {
 	//For each item in inscopeURLs...
 	for i := 0; i < len(inscopeURLs); i++ {
 		if !chainMode {
-			infoGood("IN-SCOPE: ", inscopeURLs[i])
+			infoGood("IN-SCOPE"+targetLabel()+": ", inscopeURLs[i])
 		} else {
 			fmt.Println(inscopeURLs[i])
 		}
@@ -538,7 +616,7 @@ func main() {
 		//for each unsureURLs item...
 		for i := 0; i < len(unsureURLs); i++ {
 			if !chainMode {
-				infoWarning("UNSURE: ", unsureURLs[i])
+				infoWarning("UNSURE"+targetLabel()+": ", unsureURLs[i])
 			} else {
 				fmt.Println(unsureURLs[i])
 			}
@@ -550,7 +628,7 @@ func main() {
 
 		f, err := os.OpenFile(inscopeOutputFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600) // #nosec G304 -- inscopeOutputFile is a CLI argument specified by the user running the program. It is not unsafe to allow them to open any file in their own system.
 		if err != nil {
-			crash("Unable to read output file", err)
+			return &ioError{"Unable to read output file", err}
 		}
 
 		//for each inscopeURLs item...
@@ -558,7 +636,7 @@ func main() {
 			//write it to the output file
 			_, err = f.WriteString(inscopeURLs[i] + "\n")
 			if err != nil {
-				crash("Unable to write to output file", err)
+				return &ioError{"Unable to write to output file", err}
 			}
 		}
 
@@ -569,7 +647,7 @@ func main() {
 				//write it to the output file
 				_, err = f.WriteString(unsureURLs[i] + "\n")
 				if err != nil {
-					crash("Unable to write to output file", err)
+					return &ioError{"Unable to write to output file", err}
 				}
 			}
 		}
@@ -577,69 +655,87 @@ func main() {
 		//Close the output file
 		f.Close() // #nosec G104 -- There's no harm done if we're unable to close the output file, since we're already at the end of the program.
 	}
-	cleanup()
 
+	return inscopeResult()
 }
 
-// https://stackoverflow.com/a/30948278/11490425
-func popLine(f *os.File) ([]byte, error) {
-	fi, err := f.Stat()
-	if err != nil {
-		return nil, err
+// Returns errNoneInScope if none of the targets were in scope, so that the exit code tells scripts whether there's anything to work on
+func inscopeResult() error {
+	if !foundInScope {
+		return errNoneInScope
 	}
-	buf := bytes.NewBuffer(make([]byte, 0, fi.Size()))
+	return nil
+}
 
-	_, err = f.Seek(0, io.SeekStart)
-	if err != nil {
-		return nil, err
-	}
-	_, err = io.Copy(buf, f)
-	if err != nil {
-		return nil, err
-	}
+// Loads the firebounty database. It's downloaded if it's missing or older than 24hs.
+func loadFirebountyJSON() (Firebounty, error) {
+	if firebountyJSONFileStats, err := os.Stat(firebountyJSONPath); err == nil {
+		// path/to/whatever exists
+		//check age. if age > 24hs
+		yesterday := time.Now().Add(-24 * time.Hour)
+		if firebountyJSONFileStats.ModTime().Before(yesterday) {
+			if !chainMode {
+				fmt.Println("[INFO]: +24hs have passed since the last update to the local firebounty database. Updating...")
+			}
+			if err := updateFireBountyJSON(); err != nil {
+				return Firebounty{}, err
+			}
+		}
 
-	line, err := buf.ReadBytes('\n')
-	if err != nil && err != io.EOF {
-		return nil, err
-	}
+	} else if errors.Is(err, os.ErrNotExist) {
+		//path/to/whatever does not exist
+		if !chainMode {
+			fmt.Println("[INFO]: Downloading scopes file and saving in \"" + firebountyJSONPath + "\"")
+		}
 
-	_, err = f.Seek(0, io.SeekStart)
-	if err != nil {
-		return nil, err
+		if err := updateFireBountyJSON(); err != nil {
+			return Firebounty{}, err
+		}
+
+	} else {
+		// Schrodinger: file may or may not exist. See err for details.
+		return Firebounty{}, &databaseError{"Could not verify existance of the firebounty database \"" + firebountyJSONPath + "\"", err}
 	}
-	nw, err := io.Copy(f, buf)
+
+	//open json
+	jsonFile, err := os.Open(firebountyJSONPath) // #nosec G304 -- firebountyJSONPath is a CLI argument specified by the user running the program. It is not unsafe to allow them to open any file in their own system.
 	if err != nil {
-		return nil, err
+		return Firebounty{}, &databaseError{"Couldn't open firebounty JSON. Maybe run \"chmod 777 " + firebountyJSONPath + "\"?", err}
 	}
-	err = f.Truncate(nw)
+
+	//read the json file as bytes
+	byteValue, err := io.ReadAll(jsonFile)
+	jsonFile.Close() // #nosec G104 -- No need to worry about double-closing issues, as the file is closed right after reading it.
 	if err != nil {
-		return nil, err
+		return Firebounty{}, &databaseError{"Couldn't read firebounty JSON", err}
 	}
-	err = f.Sync()
+
+	var firebountyJSON Firebounty
+	err = json.Unmarshal(byteValue, &firebountyJSON)
 	if err != nil {
-		return nil, err
+		return Firebounty{}, &databaseError{"Couldn't parse firebountyJSON into pre-defined struct", err}
 	}
 
-	_, err = f.Seek(0, io.SeekStart)
-	if err != nil {
-		return nil, err
+	for i := range firebountyJSON.Pgms {
+		firebountyJSON.Pgms[i].source = "firebounty"
+		firebountyJSON.Pgms[i].databasePath = firebountyJSONPath
 	}
-	return line, nil
+	return firebountyJSON, nil
 }
 
-func updateFireBountyJSON() {
+func updateFireBountyJSON() error {
 	// path/to/whatever does *not* exist
 	//get the big JSON from the API
 	jason, err := http.Get(firebountyAPIURL)
 	if err != nil {
-		crash("Could not download scopes from firebounty at: "+firebountyAPIURL, err)
+		return &databaseError{"Could not download scopes from firebounty at " + firebountyAPIURL, err}
 	}
 
 	//read the contents of the request
 	body, err := io.ReadAll(jason.Body)
 	jason.Body.Close() // #nosec G104 -- There is no situation in which closing the body of the request will cause an error.
 	if err != nil {
-		fmt.Println(err)
+		return &databaseError{"Could not download scopes from firebounty at " + firebountyAPIURL, err}
 	}
 
 	//delete the previous file (if it even exists)
@@ -648,241 +744,13 @@ func updateFireBountyJSON() {
 	//write to disk
 	err = os.WriteFile(firebountyJSONPath, []byte(string(body)), 0600)
 	if err != nil {
-		crash("Couldn't save firebounty json to disk as"+firebountyJSONPath, err)
+		return &databaseError{"Couldn't save firebounty json to disk as " + firebountyJSONPath, err}
 	}
 
 	if !chainMode {
 		fmt.Println("[INFO]: Scopes file saved to " + firebountyJSONPath)
 	}
-
-}
-
-// we may recieve one like the following as scope:
-// example.com
-// *.example.com
-// 192.168.0.1
-// 192.168.0.1/24
-// 192.168.0.1
-// 192.168.0.1/24
-func parseScopes(scope string, isWilcard bool, targetsListFile *os.File, outofScopesListFilepath string, firebountyOutOfScopes []Scope, parseScopeAsRegex bool) {
-	schemedScope := "http://" + scope
-
-	var CIDR *net.IPNet
-	var parseAsIP bool
-	var scopeURL *url.URL
-	var err error
-	var scopeIP net.IP
-
-	if !parseScopeAsRegex {
-		//attempt to parse current scope as a CIDR range
-		_, CIDR, _ = net.ParseCIDR(scope)
-		scopeIP := net.ParseIP(scope)
-		//if we can parse the scope as a CIDR range or as an IP address:
-		if scopeIP.String() != "<nil>" || CIDR != nil {
-			parseAsIP = true
-		} else {
-			parseAsIP = false
-			scopeURL, err = url.Parse(schemedScope)
-			if err != nil {
-				if !chainMode {
-					warning("Couldn't parse the scope " + scope + " as a valid URL.")
-				}
-				return
-			}
-		}
-	} else {
-		scope = strings.Replace(scope, ".", "\\.", -1)
-		scope = strings.Replace(scope, "*", ".*", -1)
-	}
-
-	//Read the URLs file line per line
-	//scan using bufio
-	scanner := bufio.NewScanner(targetsListFile)
-
-	for scanner.Scan() {
-		//attempt to parse current target as an IP
-		var currentTargetURL *url.URL
-		currentTargetURL, err = url.Parse(scanner.Text())
-
-		//If we couldn't parse it as is, attempt to add the "https://" prefix
-		if err != nil || currentTargetURL.Host == "" {
-			currentTargetURL, err = url.Parse("https://" + scanner.Text())
-		}
-
-		portlessHostofCurrentTarget := removePortFromHost(currentTargetURL)
-		targetIp := net.ParseIP(portlessHostofCurrentTarget)
-
-		//if it fails...
-		if (err != nil || currentTargetURL.Host == "") && !chainMode {
-			if usedstdin {
-				warning("STDIN: Couldn't parse " + scanner.Text() + " as a valid URL.")
-			} else {
-				warning(targetsListFilepath + ": Couldn't parse " + scanner.Text() + " as a valid URL.")
-			}
-
-		} else {
-			//if we have to parse this scope as a regex, and the current target is not an IP address...
-			if parseScopeAsRegex && !(targetIp.String() != "" && parseAsIP) {
-
-				//attempt to parse the scope as a regex
-				scopeRegex, err := regexp.Compile(scope)
-				if err != nil {
-					crash("There was an error parsing the scope \""+scope+"\" as a regex. This scope was parsed as a regex instead of as a URL because it has 2 or more wildcards.", err)
-				}
-
-				//if the current target host matches the regex...
-				if scopeRegex.MatchString(removePortFromHost(currentTargetURL)) {
-					if !isOutOfScope(currentTargetURL, outofScopesListFilepath, nil, firebountyOutOfScopes) {
-						if outputDomainsOnly {
-							logInScope(currentTargetURL.Hostname())
-						} else {
-							logInScope(scanner.Text())
-						}
-
-					}
-				} else if includeUnsure {
-					if !isOutOfScope(currentTargetURL, outofScopesListFilepath, nil, firebountyOutOfScopes) {
-						if outputDomainsOnly {
-							logUnsure(currentTargetURL.Hostname())
-						} else {
-							logUnsure(scanner.Text())
-						}
-					}
-				}
-
-				//we were able to parse the target as a URL
-				//if we were able to parse the target as an IP, and the scope as an IP or CIDR range
-			} else if targetIp.String() != "" && parseAsIP {
-				if parseScopeAsRegex {
-					return
-				}
-				//if the CIDR range is empty
-				if CIDR == nil {
-					//Couldn't parse scope as CIDR range, retrying as ip match")
-					if targetIp.String() == scopeIP.String() {
-						if !isOutOfScope(nil, outofScopesListFilepath, targetIp, firebountyOutOfScopes) {
-							if outputDomainsOnly {
-								logInScope(targetIp.String())
-							} else {
-								logInScope(scanner.Text())
-							}
-						}
-
-					} else if includeUnsure {
-						if !isOutOfScope(nil, outofScopesListFilepath, targetIp, firebountyOutOfScopes) {
-							if outputDomainsOnly {
-								logUnsure(targetIp.String())
-							} else {
-								logUnsure(scanner.Text())
-							}
-						}
-					}
-				} else {
-					if CIDR.Contains(targetIp) {
-						if !isOutOfScope(nil, outofScopesListFilepath, targetIp, firebountyOutOfScopes) {
-							if outputDomainsOnly {
-								logInScope(targetIp.String())
-							} else {
-								logInScope(scanner.Text())
-							}
-						}
-					} else if includeUnsure && targetIp.String() != "<nil>" {
-						if !isOutOfScope(nil, outofScopesListFilepath, targetIp, firebountyOutOfScopes) {
-							if outputDomainsOnly {
-								logUnsure(targetIp.String())
-							} else {
-								logUnsure(scanner.Text())
-							}
-						}
-					}
-				}
-
-			} else {
-				//parse the scope & target as URLs
-
-				if isWilcard {
-					//parse the scope as a URL
-
-					//if x is a subdomain of y
-					//ex: wordpress.example.com with a scope of *.example.com will give a match
-					//we DON'T do it by splitting on dots and matching, because that would cause errors with domains that have two top-level-domains (gov.br for example)
-					if strings.HasSuffix(removePortFromHost(currentTargetURL), scopeURL.Host) {
-						if !isOutOfScope(currentTargetURL, outofScopesListFilepath, nil, firebountyOutOfScopes) {
-							if outputDomainsOnly {
-								logInScope(currentTargetURL.Hostname())
-							} else {
-								logInScope(scanner.Text())
-							}
-						}
-
-					} else if includeUnsure {
-						if !isOutOfScope(currentTargetURL, outofScopesListFilepath, nil, firebountyOutOfScopes) {
-							if outputDomainsOnly {
-								logUnsure(currentTargetURL.Hostname())
-							} else {
-								logUnsure(scanner.Text())
-							}
-						}
-					}
-				} else {
-					if removePortFromHost(currentTargetURL) == scopeURL.Host {
-						if !isOutOfScope(currentTargetURL, outofScopesListFilepath, nil, firebountyOutOfScopes) {
-							if outputDomainsOnly {
-								logInScope(currentTargetURL.Hostname())
-							} else {
-								logInScope(scanner.Text())
-							}
-						}
-
-					} else if includeUnsure {
-						if !isOutOfScope(currentTargetURL, outofScopesListFilepath, nil, firebountyOutOfScopes) {
-							if outputDomainsOnly {
-								logUnsure(currentTargetURL.Hostname())
-							} else {
-								logUnsure(scanner.Text())
-							}
-						}
-					}
-				}
-
-			}
-		}
-
-	}
-
-	if err := scanner.Err(); err != nil {
-		crash("Could not read URL List file successfully", err)
-	}
-}
-
-func parseScopesWrapper(scope string, explicitLevel int, targetsListFile *os.File, outofScopesListFilepath string, firebountyOutOfScopes []Scope) {
-
-	//if we have a wildcard domain
-	if strings.HasPrefix(scope, "*.") {
-		//shorter way of saying if explicitLevel == 2 || explicitLevel ==1
-		if explicitLevel != 3 && strings.Count(scope, "*") == 1 {
-			//remove wildcard ("*.")
-			scope = strings.ReplaceAll(scope, "*.", "")
-			parseScopes(scope, true, targetsListFile, outofScopesListFilepath, firebountyOutOfScopes, false)
-		}
-
-		//if the scope is in a weird wildcard format, containing more than one wildcard...
-	} else if strings.Contains(scope, "*") {
-		parseScopes(scope, true, targetsListFile, outofScopesListFilepath, firebountyOutOfScopes, true)
-	} else if explicitLevel == 1 {
-		//this is NOT a wildcard domain, but we'll treat it as such anyway
-		parseScopes(scope, true, targetsListFile, outofScopesListFilepath, firebountyOutOfScopes, false)
-	} else {
-		//this is NOT a wildcard domain. we will parse it explicitly
-		parseScopes(scope, false, targetsListFile, outofScopesListFilepath, firebountyOutOfScopes, false)
-	}
-}
-
-func crash(message string, err error) {
-	cleanup()
-	fmt.Fprintf(os.Stderr, string(colorRed)+"[ERROR]: "+message+string(colorReset)+"\n\n")
-	fmt.Fprintf(os.Stderr, string(colorRed)+"Error stacktrace: "+string(colorReset)+"\n")
-	panic(err)
+	return nil
 }
 
 func warning(message string) {
@@ -897,170 +765,6 @@ func infoWarning(prefix string, message string) {
 	fmt.Print(string(colorYellow) + "[+] " + prefix + string(colorReset) + message + "\n")
 }
 
-func removePortFromHost(url *url.URL) string {
-	//code readability > efficiency
-	portless := strings.Replace(string(url.Host), string(url.Port()), "", 1)
-	//obligatory cleanup ("192.168.1.1:" -> "192.168.1.1")
-	portless = strings.Replace(portless, ":", "", 1)
-	return portless
-}
-
-// out-of-scopes are parsed as --explicit-level==2
-func isOutOfScope(targetURL *url.URL, outofScopesListFilepath string, targetIP net.IP, firebountyOutOfScopes []Scope) bool {
-	var err error
-
-	if outofScopesListFilepath != "" {
-		//user chose to use their own out-of-scopes file, or we detected a .noscope file
-		if _, err = os.Stat(outofScopesListFilepath); err == nil {
-			// path/to/whatever exists
-			//open the file
-			//https://stackoverflow.com/a/16615559/11490425
-			outOfScopesFile, err := os.Open(outofScopesListFilepath) // #nosec G304 -- outofScopesListFilepath is a CLI argument specified by the user running the program. It is not unsafe to allow them to open any file in their own system.
-			if err != nil {
-				crash("Could not open "+outofScopesListFilepath, err)
-			}
-
-			//Read the file line per line using bufio
-			outofScopeScanner := bufio.NewScanner(outOfScopesFile)
-
-			for outofScopeScanner.Scan() {
-
-				if parseOutOfScopes(targetURL, outofScopeScanner.Text(), targetIP) {
-					return true
-				}
-			}
-			err = outOfScopesFile.Close()
-			if err != nil {
-				crash("Couldn't close "+outofScopesListFilepath+" because it was already closed.", err)
-			}
-			return false
-
-		} else if errors.Is(err, os.ErrNotExist) {
-			// path/to/whatever does *not* exist
-			crash("OutOfScopes file supplied, but it does not exist!", err)
-
-		} else {
-			// Schrodinger: file may or may not exist. See err for details.
-			crash("Couldn't verify existance of outofscopesFile", err)
-
-		}
-	} else {
-		//check target agains firebounty out-of-scopes
-		//for every outOfScope
-		for outOfScopeCounter := 0; outOfScopeCounter < len(firebountyOutOfScopes); outOfScopeCounter++ {
-			//if the scope_type is web_application and it's not empty
-			if firebountyOutOfScopes[outOfScopeCounter].Scope_type == "web_application" && firebountyOutOfScopes[outOfScopeCounter].Scope != "" {
-				outOfScope := firebountyOutOfScopes[outOfScopeCounter].Scope
-				if !chainMode {
-					//alert the user about potentially mis-configured bug-bounty program
-					if outOfScope[0:4] == "com." || outOfScope[0:4] == "org." {
-						warning("Scope starting with \"com.\" or \"org. found. This may be a sign of a misconfigured bug bounty program. Consider editing the \"" + firebountyJSONPath + " file and removing the faulty entries. Also, report the failure to the maintainers of the bug bounty program.")
-					}
-				}
-				if parseOutOfScopes(targetURL, outOfScope, targetIP) {
-					return true
-				}
-			}
-
-		}
-	}
-
-	//if we got no matches for any outOfScope
-	return false
-}
-
-// Returns true if the targetURL is out of scope, false otherwise
-// Only targetURL or targetIP should be non-nil
-// If both are specified, targetURL will be used
-// If both are nil, the function will return false
-func parseOutOfScopes(targetURL *url.URL, outOfScope string, targetIP net.IP) bool {
-
-	if targetURL != nil {
-		//parse target as a URL
-
-		//if the outofscope starts with a wildcard...
-		if strings.HasPrefix(outOfScope, "*.") && strings.Count(outOfScope, "*") == 1 {
-			outOfScopeURL, err := url.Parse("https://" + outOfScope)
-			if err != nil {
-				if !chainMode {
-					warning("Couldn't parse out-of-scope \"" + outOfScope + "\" as a URL.")
-				}
-				return false
-			}
-
-			//if x is a subdomain of y
-			//ex: wordpress.example.com with a scope of *.example.com will give a match
-			//we DON'T do it by splitting on dots and matching, because that would cause errors with domains that have two top-level-domains (gov.br for example)
-			if strings.HasSuffix(removePortFromHost(targetURL), outOfScopeURL.Host) {
-				return true
-
-			}
-
-			//if the outofscope has more than one wildcard...
-		} else if strings.Contains(outOfScope, "*") {
-
-			//parse as regex
-			outOfScope = strings.Replace(outOfScope, ".", "\\.", -1)
-			outOfScope = strings.Replace(outOfScope, "*", ".*", -1)
-
-			outOfScopeRegex, err := regexp.Compile(outOfScope)
-			if err != nil {
-				crash("There was an error parsing the noscope \""+outOfScope+"\" as a regex. This scope was parsed as a regex instead of as a URL because it has 2 or more wildcards.", err)
-			}
-
-			if outOfScopeRegex.MatchString(removePortFromHost(targetURL)) {
-				return true
-			}
-		} else {
-			// The scope has no wildcards
-
-			var outOfScopeURL *url.URL
-			var err error
-
-			schemeRegex, _ := regexp.Compile(`^\w+:`)
-			//if the outofscope starts with a scheme...
-			if schemeRegex.MatchString(outOfScope) {
-				// Parse it as it is
-				outOfScopeURL, err = url.Parse(outOfScope)
-				if err != nil {
-					if !chainMode {
-						warning("Couldn't parse out-of-scope \"" + outOfScope + "\" as a URL.")
-					}
-					return false
-				}
-			} else {
-				// Add a scheme to it so it can be parsed as a URL
-				outOfScopeURL, err = url.Parse("https://" + outOfScope)
-				if err != nil {
-					if !chainMode {
-						warning("Couldn't parse out-of-scope \"" + colorBlue + "https://" + colorYellow + outOfScope + "\" as a URL.")
-					}
-					return false
-				}
-			}
-
-			if removePortFromHost(targetURL) == outOfScopeURL.Host {
-				return true
-
-			}
-		}
-	} else {
-		//IP mode
-		//attempt to parse current outOfScope as an IP
-		outOfScopeIp := net.ParseIP(outOfScope)
-		//if we can parse the current outOfScope as an IP...
-		if outOfScopeIp != nil {
-			//try IP match
-			if targetIP.String() == outOfScopeIp.String() {
-				return true
-			}
-		}
-	}
-
-	//if nothing matched
-	return false
-}
-
 //======================================================================================
 // The following code is from tomnomnom's inscope project:
 // https://github.com/tomnomnom/hacks/tree/master/inscope
@@ -1091,17 +795,26 @@ func searchForFileBackwards(filename string) (string, error) {
 
 //======================================================================================
 
-func cleanup() {
-	if usedstdin {
-		//Developers using temporary files are expected to clean up after themselves.
-		//https://superuser.com/a/296827
-		_ = targetsListFile.Close()
-		err := os.Remove(targetsListFile.Name())
-		if err != nil {
-			fmt.Fprintf(os.Stderr, string(colorRed)+"[ERROR]: Unable to delete the temporary file at '"+targetsListFile.Name()+"'. Access permissions to this system's temp folder might have changed since the program started running. Make sure to delete the file manually to avoid clutter in your temp directory."+string(colorReset)+"\n")
-			panic(err)
+// Returns every file with the filename in the current directory and in its parents, starting with the nearest one
+func searchForFilesBackwards(filename string) ([]string, error) {
+	pwd, err := filepath.Abs(".")
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for {
+		if _, err := os.Stat(filepath.Join(pwd, filename)); err == nil {
+			paths = append(paths, filepath.Join(pwd, filename))
+		}
+
+		newPwd := filepath.Dir(pwd)
+		if newPwd == pwd {
+			break
 		}
+		pwd = newPwd
 	}
+	return paths, nil
 }
 
 func logInScope(url string) {
@@ -1109,7 +822,7 @@ func logInScope(url string) {
 }
 
 func logUnsure(url string) {
-	unsureURLs = append(inscopeURLs, url)
+	unsureURLs = append(unsureURLs, url)
 }
 
 // Receives a slice of strings and returns a new slice with duplicates removed
@@ -1125,7 +838,15 @@ func removeDuplicateStr(strSlice []string) []string {
 	return list
 }
 
-func parseCompany(company string, firebountyJSON Firebounty, companyCounter int, explicitLevel int, outofScopesListFilepath string) {
+// Reports whether the host ends with a suffix of the public suffix list, such as ".com" or ".github.io". Hosts like "localhost" or "example.internal" don't.
+func hasPublicSuffix(host string) bool {
+	suffix, icann := publicsuffix.PublicSuffix(host)
+	//unlisted TLDs fall back to their last label, which isn't managed by ICANN. Privately managed suffixes always have more than one label.
+	return icann || strings.Contains(suffix, ".")
+}
+
+// Prints the details of the matched company, and returns its in-scope and out-of-scope rules
+func parseCompany(company string, firebountyJSON Firebounty, companyCounter int) (includes []scope.Rule, excludes []scope.Rule, err error) {
 	//match found!
 	if !chainMode {
 		fmt.Print("[+] Search for \"" + company + "\" matched the company " + string(colorGreen) + firebountyJSON.Pgms[companyCounter].Name + string(colorReset) + "!\n")
@@ -1133,9 +854,9 @@ func parseCompany(company string, firebountyJSON Firebounty, companyCounter int,
 		// Print the details of the matched company in a readable format
 
 		// Get the last date the cached database was updated
-		info, err := os.Stat(firebountyJSONPath)
+		info, err := os.Stat(firebountyJSON.Pgms[companyCounter].databasePath)
 		if err != nil {
-			crash("Error getting file information for the database file at "+firebountyJSONFilename, err)
+			return nil, nil, &databaseError{"Error getting file information for the database file at " + firebountyJSON.Pgms[companyCounter].databasePath, err}
 		}
 		// info.Atime_ns now contains the last access time
 		// (in nanoseconds since the unix epoch)
@@ -1144,7 +865,9 @@ func parseCompany(company string, firebountyJSON Firebounty, companyCounter int,
 		fmt.Println("[+] Last updated: " + lastUpdated)
 
 		// Print the details of the matched company in a readable format
-		fmt.Println("[+] Firebounty URL: " + firebountyJSON.Pgms[companyCounter].Firebounty_url)
+		if firebountyJSON.Pgms[companyCounter].Firebounty_url != "" {
+			fmt.Println("[+] Firebounty URL: " + firebountyJSON.Pgms[companyCounter].Firebounty_url)
+		}
 		fmt.Println("[+] Program URL: " + firebountyJSON.Pgms[companyCounter].Url)
 
 		// Print the in-scope rules
@@ -1153,6 +876,16 @@ func parseCompany(company string, firebountyJSON Firebounty, companyCounter int,
 			fmt.Println("\t[+] " + inscope.Scope_type + ": " + inscope.Scope)
 		}
 
+		// Print the whitelisted rules
+		if includeWhitelisted {
+			if whitelisted := whitelistedScopes(firebountyJSON, firebountyJSON.Pgms[companyCounter]); len(whitelisted) > 0 {
+				fmt.Println("\n[+] Whitelisted rules: ")
+				for _, inscope := range whitelisted {
+					fmt.Println("\t[+] " + inscope.Scope)
+				}
+			}
+		}
+
 		// Print the out-of-scope rules
 		fmt.Println("\n[+] Out-of-scope rules: ")
 		for _, noscope := range firebountyJSON.Pgms[companyCounter].Scopes.Out_of_scopes {
@@ -1162,37 +895,456 @@ func parseCompany(company string, firebountyJSON Firebounty, companyCounter int,
 		fmt.Println("\n[+] Analysis started...")
 
 	}
+
+	//the scopes of unsupported types (such as "hardware" or "other") are counted, to let the user know they were skipped
+	skippedScopes := make(map[string]int)
+
 	//for every scope in the program
-	for scopeCounter := 0; scopeCounter < len(firebountyJSON.Pgms[companyCounter].Scopes.In_scopes); scopeCounter++ {
-		//if the scope type is "web_application" and it's not empty
-		if firebountyJSON.Pgms[companyCounter].Scopes.In_scopes[scopeCounter].Scope_type == "web_application" && firebountyJSON.Pgms[companyCounter].Scopes.In_scopes[scopeCounter].Scope != "" {
+	for _, inscope := range firebountyJSON.Pgms[companyCounter].Scopes.In_scopes {
+		if inscope.Scope == "" {
+			continue
+		}
+		asset, supported := scopeAssetType(inscope.Scope_type)
+		if !supported {
+			skippedScopes[inscope.Scope_type]++
+			continue
+		}
+		//mobile apps, repositories and executables are matched against targets of their own type
+		if asset != scope.WebAsset {
+			includes = append(includes, parseProgramAssetRules(inscope.Scope, asset, firebountyJSON.Pgms[companyCounter].source, firebountyJSON.Pgms[companyCounter].Name)...)
+			continue
+		}
 
-			scope := firebountyJSON.Pgms[companyCounter].Scopes.In_scopes[scopeCounter].Scope
+		if !chainMode {
+			//alert the user about potentially mis-configured bug-bounty program
+			if target, err := scope.ParseTarget(inscope.Scope); err == nil {
+				if !hasPublicSuffix(target.Host) {
+					warning("\"" + inscope.Scope + "\". Does not have a public Top Level Domain (TLD). This may be a sign of a misconfigured bug bounty program. Consider editing the \"" + firebountyJSON.Pgms[companyCounter].databasePath + " file and removing the faulty entries. Also, report the failure to the mainters of the bug bounty program.")
+				}
+			}
+		}
+
+		includes = append(includes, parseProgramRules(inscope.Scope, firebountyJSON.Pgms[companyCounter].source, firebountyJSON.Pgms[companyCounter].Name)...)
+	}
+
+	//the whitelisted domains are in scope too, but they're tagged so they can be told apart from the in-scopes of the program
+	if includeWhitelisted {
+		for _, inscope := range whitelistedScopes(firebountyJSON, firebountyJSON.Pgms[companyCounter]) {
+			includes = append(includes, parseProgramRules(inscope.Scope, firebountyJSON.Pgms[companyCounter].source+":white_listed", firebountyJSON.Pgms[companyCounter].Name)...)
+		}
+	}
+
+	//for every outOfScope in the program
+	for _, noscope := range firebountyJSON.Pgms[companyCounter].Scopes.Out_of_scopes {
+		if noscope.Scope == "" {
+			continue
+		}
+		asset, supported := scopeAssetType(noscope.Scope_type)
+		if !supported {
+			skippedScopes[noscope.Scope_type]++
+			continue
+		}
+		if asset != scope.WebAsset {
+			excludes = append(excludes, parseProgramAssetRules(noscope.Scope, asset, firebountyJSON.Pgms[companyCounter].source, firebountyJSON.Pgms[companyCounter].Name)...)
+			continue
+		}
+
+		if !chainMode {
+			//alert the user about potentially mis-configured bug-bounty program
+			if strings.HasPrefix(noscope.Scope, "com.") || strings.HasPrefix(noscope.Scope, "org.") {
+				warning("Scope starting with \"com.\" or \"org. found. This may be a sign of a misconfigured bug bounty program. Consider editing the \"" + firebountyJSON.Pgms[companyCounter].databasePath + " file and removing the faulty entries. Also, report the failure to the maintainers of the bug bounty program.")
+			}
+		}
+
+		excludes = append(excludes, parseProgramRules(noscope.Scope, firebountyJSON.Pgms[companyCounter].source, firebountyJSON.Pgms[companyCounter].Name)...)
+	}
+
+	warnSkippedScopes(skippedScopes, firebountyJSON.Pgms[companyCounter].Name)
+	return includes, excludes, nil
+}
+
+// Lets the user know about the scopes of unsupported types (such as "hardware" or "other"), which were skipped
+func warnSkippedScopes(skippedScopes map[string]int, programName string) {
+	if chainMode || len(skippedScopes) == 0 {
+		return
+	}
+	scopeTypes := make([]string, 0, len(skippedScopes))
+	for scopeType := range skippedScopes {
+		scopeTypes = append(scopeTypes, scopeType)
+	}
+	sort.Strings(scopeTypes)
+	for _, scopeType := range scopeTypes {
+		warning("Skipped " + strconv.Itoa(skippedScopes[scopeType]) + " \"" + scopeType + "\" scope(s) of " + programName + ", since targets of that type can't be classified.")
+	}
+}
 
+// The scope types of the firebounty database, of the bounty-targets-data files and of the platform APIs, and the type of asset of their scopes. The types are lowercase'd, with underscores instead of hyphens.
+// Any other type (such as "hardware" or "other") is not supported, and its scopes are skipped with a warning.
+var scopeAssetTypes = map[string]scope.AssetType{
+	"web_application":            scope.WebAsset,
+	"ip_range":                   scope.WebAsset,
+	"iprange":                    scope.WebAsset,
+	"cidr":                       scope.WebAsset,
+	"ip_address":                 scope.WebAsset,
+	"network":                    scope.WebAsset,
+	"android_application":        scope.AndroidAsset,
+	"android":                    scope.AndroidAsset,
+	"google_play_app_id":         scope.AndroidAsset,
+	"other_apk":                  scope.AndroidAsset,
+	"mobile_application_android": scope.AndroidAsset,
+	"ios_application":            scope.IOSAsset,
+	"ios":                        scope.IOSAsset,
+	"apple_store_app_id":         scope.IOSAsset,
+	"other_ipa":                  scope.IOSAsset,
+	"testflight":                 scope.IOSAsset,
+	"mobile_application_ios":     scope.IOSAsset,
+	"source_code":                scope.RepositoryAsset,
+	"sourcecode":                 scope.RepositoryAsset,
+	"code":                       scope.RepositoryAsset,
+	"executable":                 scope.ExecutableAsset,
+	"downloadable_executables":   scope.ExecutableAsset,
+}
+
+// Returns the type of asset of a scope type, and whether the scope type is supported at all
+func scopeAssetType(scopeType string) (scope.AssetType, bool) {
+	asset, supported := scopeAssetTypes[strings.ReplaceAll(strings.ToLower(scopeType), "-", "_")]
+	return asset, supported
+}
+
+// Parses a non-web scope of a bug bounty program, such as an Android package name
+func parseProgramAssetRules(programScope string, asset scope.AssetType, source string, programName string) []scope.Rule {
+	rule, err := scope.ParseAssetRule(programScope, asset)
+	if err != nil {
+		if !chainMode {
+			warning(err.Error())
+		}
+		return nil
+	}
+	rule.Source = source
+	rule.Program = programName
+	return []scope.Rule{rule}
+}
+
+// Parses a scope of a platform API. Every platform lists the scope types that are web hosts (isWeb), such as "WILDCARD" on HackerOne, and those are parsed as usual.
+// The rest (mobile apps, source code...) are parsed as the type of asset given by scopeAssetType, and the scopes of unsupported types are counted in skippedScopes.
+func parsePlatformScope(programScope string, scopeType string, isWeb bool, source string, programName string, skippedScopes map[string]int) []scope.Rule {
+	if isWeb {
+		return parseProgramRules(programScope, source, programName)
+	}
+	asset, supported := scopeAssetType(scopeType)
+	if !supported {
+		skippedScopes[scopeType]++
+		return nil
+	}
+	if asset == scope.WebAsset {
+		return parseProgramRules(programScope, source, programName)
+	}
+	return parseProgramAssetRules(programScope, asset, source, programName)
+}
+
+// Returns the "White_listed" domains of a program as web_application scopes. The domains that are already in-scopes of the program are skipped.
+func whitelistedScopes(firebountyJSON Firebounty, program Program) []Scope {
+	if program.Slug == "" {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, inscope := range program.Scopes.In_scopes {
+		if inscope.Scope_type == "web_application" {
+			seen[strings.ToLower(inscope.Scope)] = true
+		}
+	}
+
+	var scopes []Scope
+	for _, whitelisted := range firebountyJSON.White_listed {
+		domain := strings.ToLower(strings.TrimSpace(whitelisted.Regex))
+		if whitelisted.Program_slug != program.Slug || domain == "" || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		scopes = append(scopes, Scope{Scope: domain, Scope_type: "web_application"})
+	}
+	return scopes
+}
+
+// Parses a scope of a bug bounty program, such as a firebounty, HackerOne or Bugcrowd program
+func parseProgramRules(programScope string, source string, programName string) []scope.Rule {
+	rules := parseRules([]string{programScope}, source)
+	for i := range rules {
+		rules[i].Program = programName
+	}
+	return rules
+}
+
+// Reads a scopes file line per line, and parses every scope. The negated ("!") scopes are returned separately.
+func loadRulesFile(path string) (rules []scope.Rule, negated []scope.Rule, err error) {
+	return loadRulesFiles(path, nil)
+}
+
+// Loads every scopes file matched by the glob pattern, such as "scopes/*.txt". A pattern that is the path of an existing file is loaded as is.
+// ancestors are the absolute paths of the files that @include'd these files, to detect include cycles.
+func loadRulesFiles(pattern string, ancestors []string) (rules []scope.Rule, negated []scope.Rule, err error) {
+	paths := []string{pattern}
+	if _, err := os.Stat(pattern); err != nil && strings.ContainsAny(pattern, "*?[") {
+		paths, err = filepath.Glob(pattern)
+		if err != nil {
+			return nil, nil, &usageError{"Invalid file pattern " + pattern, err}
+		}
+		if len(paths) == 0 {
+			return nil, nil, &usageError{"No files matched " + pattern, os.ErrNotExist}
+		}
+	}
+
+	for _, path := range paths {
+		fileRules, fileNegated, err := loadRulesFileFrom(path, ancestors)
+		if err != nil {
+			return nil, nil, err
+		}
+		rules = append(rules, fileRules...)
+		negated = append(negated, fileNegated...)
+	}
+	return rules, negated, nil
+}
+
+func loadRulesFileFrom(path string, ancestors []string) (rules []scope.Rule, negated []scope.Rule, err error) {
+	absolutePath, err := includedPath(path, ancestors)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scopesFile, err := os.Open(path) // #nosec G304 -- path is a CLI argument specified by the user running the program, or a .inscope/.noscope file found by us. It is not unsafe to allow them to open any file in their own system.
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, &usageError{path + " does not exist", err}
+	} else if err != nil {
+		return nil, nil, &ioError{"Could not open " + path, err}
+	}
+	defer scopesFile.Close() // #nosec G307 -- The file is only read from.
+
+	//included paths are relative to the directory of the file that includes them
+	include := func(pattern string) ([]scope.Rule, []scope.Rule, error) {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+		return loadRulesFiles(pattern, append(slices.Clone(ancestors), absolutePath))
+	}
+	return readRules(scopesFile, path, include)
+}
+
+// Returns the absolute path of a scope file, or an error if the file is already one of the files that include it
+func includedPath(path string, ancestors []string) (string, error) {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return "", &ioError{"Could not resolve the path " + path, err}
+	}
+	if slices.Contains(ancestors, absolutePath) {
+		return "", &usageError{"Include cycle detected: " + strings.Join(append(ancestors, absolutePath), " -> "), nil}
+	}
+	return absolutePath, nil
+}
+
+// Reads a list of scopes line per line, and parses every scope. The source, line number and annotations are saved in every rule.
+// The negated ("!") scopes are returned separately, since they mean the opposite of the rest of the file.
+// The rules of the "@include" lines are loaded with the include function. If it's nil, the "@include" lines are ignored.
+func readRules(reader io.Reader, source string, include func(pattern string) ([]scope.Rule, []scope.Rule, error)) (rules []scope.Rule, negated []scope.Rule, err error) {
+	lineNumber := 0
+
+	//Read the file line per line using bufio
+	scopesScanner := bufio.NewScanner(reader)
+	for scopesScanner.Scan() {
+		lineNumber++
+		line, err := parseScopeLine(scopesScanner.Text())
+		if err != nil {
 			if !chainMode {
-				//attempt to parse current target as an IP
-				var currentTargetURL *url.URL
-				currentTargetURL, err := url.Parse(scope)
+				warning(source + ":" + strconv.Itoa(lineNumber) + ": " + err.Error())
+			}
+			continue
+		}
+		if line.empty {
+			continue
+		}
 
-				//If we couldn't parse it as is, attempt to add the "https://" prefix
-				if err != nil || currentTargetURL.Host == "" {
-					currentTargetURL, _ = url.Parse("https://" + scope)
+		if line.include != "" {
+			if include == nil {
+				if !chainMode {
+					warning(source + ":" + strconv.Itoa(lineNumber) + ": @include is only supported in scope files")
 				}
+				continue
+			}
+			includedRules, includedNegated, err := include(line.include)
+			if err != nil {
+				return nil, nil, err
+			}
+			rules = append(rules, includedRules...)
+			negated = append(negated, includedNegated...)
+			continue
+		}
 
-				portlessHostofCurrentTarget := removePortFromHost(currentTargetURL)
+		line.rule.Source = source
+		line.rule.Line = lineNumber
+		if line.negated {
+			negated = append(negated, line.rule)
+		} else {
+			rules = append(rules, line.rule)
+		}
+	}
+	if err := scopesScanner.Err(); err != nil {
+		return nil, nil, &ioError{"Could not read " + source + " successfully", err}
+	}
 
-				//alert the user about potentially mis-configured bug-bounty program
-				_, scopeHasValidTLD := publicsuffix.PublicSuffix(portlessHostofCurrentTarget)
+	return rules, negated, nil
+}
 
-				if !scopeHasValidTLD && currentTargetURL.Host != "" {
-					warning("\"" + scope + "\". Does not have a public Top Level Domain (TLD). This may be a sign of a misconfigured bug bounty program. Consider editing the \"" + firebountyJSONPath + " file and removing the faulty entries. Also, report the failure to the mainters of the bug bounty program.")
-				}
+// scopeLine is a line of a scope file
+type scopeLine struct {
+	rule scope.Rule
+	// negated lines start with "!", and mean the opposite of the rest of the file. In a .inscope file, "!admin.example.com" is out of scope.
+	negated bool
+	// empty lines are blank, or only have a comment
+	empty bool
+	// include is the path or glob of an "@include" line
+	include string
+}
+
+// Parses a line of a scope file. We may recieve one like the following:
+//
+//	*.example.com
+//	# a comment
+//	(a blank line)
+//	!admin.example.com
+//	api.example.com  # max-severity=high owner=team-a
+//	@include ../shared/exclusions.scope
+//
+// Comments start with a "#" at the beginning of the line, or after a space. Every key=value pair of the comment is saved as an annotation of the rule.
+func parseScopeLine(text string) (scopeLine, error) {
+	var line scopeLine
+
+	text, comment := splitComment(text)
+	text = strings.TrimSpace(text)
+	if text == "" {
+		line.empty = true
+		return line, nil
+	}
+
+	if directive, pattern, _ := strings.Cut(text, " "); directive == "@include" {
+		line.include = strings.TrimSpace(pattern)
+		if line.include == "" {
+			return line, errors.New("@include requires a path")
+		}
+		return line, nil
+	}
+
+	if strings.HasPrefix(text, "!") {
+		line.negated = true
+		text = strings.TrimSpace(strings.TrimPrefix(text, "!"))
+	}
+
+	rule, err := scope.ParseRule(text)
+	if err != nil {
+		return line, err
+	}
+	for _, field := range strings.Fields(comment) {
+		if key, value, found := strings.Cut(field, "="); found && key != "" {
+			if rule.Annotations == nil {
+				rule.Annotations = make(map[string]string)
+			}
+			rule.Annotations[key] = value
+		}
+	}
+	line.rule = rule
+	return line, nil
+}
+
+// Splits a line of a scope file into its scope and its comment. A "#" inside of a scope, like the fragment of "https://example.com/#/admin", doesn't start a comment.
+func splitComment(text string) (string, string) {
+	for i, r := range text {
+		if r == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t') {
+			return text[:i], text[i+1:]
+		}
+	}
+	return text, ""
+}
+
+// Parses every scope, and warns the user about the ones that couldn't be parsed. The source is saved in every rule, to let the user know where each rule came from.
+func parseRules(scopes []string, source string) []scope.Rule {
+	rules, errs := scope.ParseRules(scopes)
+	if !chainMode {
+		for _, err := range errs {
+			warning(err.Error())
+		}
+	}
+	for i := range rules {
+		rules[i].Source = source
+	}
+	return rules
+}
+
+// Classifies a single target and logs it if it's in scope (or unsure)
+func classifyTarget(matcher *scope.Matcher, line string) error {
+	if explainMode {
+		trace := matcher.ExplainAsset(line, targetAsset)
+		printTrace(trace)
+		foundInScope = foundInScope || trace.Verdict == scope.InScope
+		return nil
+	}
+
+	target, err := scope.ParseAssetTarget(line, targetAsset)
+	if results != nil {
+		var verdict scope.Verdict
+		var reason scope.Reason
+		if err != nil {
+			verdict, reason = scope.Unparseable, scope.Reason{Err: err}
+		} else {
+			verdict, reason = matcher.ClassifyTarget(target)
+		}
+		foundInScope = foundInScope || verdict == scope.InScope
+		err = results.write(newTargetResult(target, verdict, reason))
+		if err != nil {
+			return &ioError{"Unable to write the results", err}
+		}
+		return nil
+	}
+
+	if err != nil {
+		if !chainMode {
+			if usedstdin {
+				warning("STDIN: Couldn't parse " + line + " as a valid " + targetDescription() + ".")
+			} else {
+				warning(targetsListFilepath + ": Couldn't parse " + line + " as a valid " + targetDescription() + ".")
 			}
+		}
+		return nil
+	}
 
-			parseScopesWrapper(scope, explicitLevel, targetsListFile, outofScopesListFilepath, firebountyJSON.Pgms[companyCounter].Scopes.Out_of_scopes)
+	output := line
+	if outputDomainsOnly {
+		output = target.Hostname()
+	}
 
+	switch verdict, _ := matcher.ClassifyTarget(target); verdict {
+	case scope.InScope:
+		foundInScope = true
+		logInScope(output)
+	case scope.Unsure:
+		if includeUnsure {
+			logUnsure(output)
 		}
 	}
+	return nil
+}
+
+// Labels the non-web targets with their type, such as " (android)"
+func targetLabel() string {
+	if targetAsset == scope.WebAsset {
+		return ""
+	}
+	return " (" + targetAsset.String() + ")"
+}
+
+// Describes the type of the targets, such as "URL" or "android asset"
+func targetDescription() string {
+	if targetAsset == scope.WebAsset {
+		return "URL"
+	}
+	return targetAsset.String() + " asset"
 }
 
 func isVSCodeDebug() bool {
@@ -1,15 +1,17 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"net"
 	"net/http"
-	"net/url"
+	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
 	"github.com/zenizh/go-capturer"
 )
 
@@ -44,77 +46,27 @@ func equals(tb testing.TB, exp, act interface{}) {
 	}
 }
 
+// setGlobal sets a global variable for the duration of the test
+func setGlobal[T any](tb testing.TB, global *T, value T) {
+	previous := *global
+	*global = value
+	tb.Cleanup(func() { *global = previous })
+}
+
 //========================================================================
 //========================================================================
 //========================================================================
 
-func Test_parseOutOfScopes(t *testing.T) {
-	// Simple test - inscope URL
-	assetURL, _ := url.Parse("https://example.com")
-	outOfScopeString := "zendesk*.example.com"
-	value := parseOutOfScopes(assetURL, outOfScopeString, nil)
-	equals(t, false, value)
-
-	// Simple test - out of scope URL
-	assetURL, _ = url.Parse("https://zendesk.internal.example.com")
-	outOfScopeString = "zendesk*.example.com"
-	value = parseOutOfScopes(assetURL, outOfScopeString, nil)
-	equals(t, true, value)
-
-	// Simple test - in-scope URL with a URL-like out-of-scope string
-	assetURL, _ = url.Parse("https://zendesk.internal.example.com")
-	outOfScopeString = "https://sometool.internal.example.com"
-	value = parseOutOfScopes(assetURL, outOfScopeString, nil)
-	equals(t, false, value)
-
-	// Simple test - out-of-scope URL with a URL-like out-of-scope string
-	assetURL, _ = url.Parse("https://zendesk.internal.example.com")
-	outOfScopeString = "https://zendesk.internal.example.com"
-	value = parseOutOfScopes(assetURL, outOfScopeString, nil)
-	equals(t, true, value)
-
-	// Test - in-scope URL with a URL-like out-of-scope string with an unusual scheme
-	assetURL, _ = url.Parse("https://zendesk.internal.example.com")
-	outOfScopeString = "mongodb://sometool.internal.example.com"
-	value = parseOutOfScopes(assetURL, outOfScopeString, nil)
-	equals(t, false, value)
-
-	// Test - out-of-scope URL with a URL-like out-of-scope string with an unusual scheme
-	assetURL, _ = url.Parse("https://zendesk.internal.example.com")
-	outOfScopeString = "mongodb://zendesk.internal.example.com"
-	value = parseOutOfScopes(assetURL, outOfScopeString, nil)
-	equals(t, true, value)
-
-	// Test with a bad function invocation, providing both an assetURL and an assetIP
-	// Only the assetURL should be used in this case
-	assetURL, _ = url.Parse("https://zendesk.internal.example.com")
-	outOfScopeString = "zendesk*.example.com"
-	assetIP := net.ParseIP("127.0.0.1")
-	value = parseOutOfScopes(assetURL, outOfScopeString, assetIP)
-	equals(t, true, value)
-
-	// Test with a bad function invocation, providing both assetURL and assetIP as nil
-	// The function should return false
-	assetURL = nil
-	outOfScopeString = "127.0.0.1"
-	assetIP = nil
-	value = parseOutOfScopes(assetURL, outOfScopeString, assetIP)
-	equals(t, false, value)
-}
-
-func Example_parseOutOfScopes() {
-	// Test with an invalid out-of-scope string
-	// In context, this function would print a warning to stderr and return false
+func Example_parseRules() {
+	// Test with an invalid scope string
+	// In context, this function would print a warning to stderr and skip the scope
 	// However, for testing purposes, we will just check the stederr output
-	assetURL, _ := url.Parse("https://example.com")
-	outOfScopeString := "this is not even close to a URL"
-
 	out := capturer.CaptureStderr(func() {
-		_ = parseOutOfScopes(assetURL, outOfScopeString, nil)
+		_ = parseRules([]string{"this is not even close to a URL"}, "test")
 	})
 
 	fmt.Println(out)
-	// Output: [33m[WARNING]: Couldn't parse out-of-scope "[38;2;0;204;255mhttps://[33mthis is not even close to a URL" as a URL.[0m
+	// Output: [33m[WARNING]: couldn't parse the scope "this is not even close to a URL" as a valid URL[0m
 }
 
 func Test_updateFireBountyJSON(t *testing.T) {
@@ -145,16 +97,191 @@ func Test_updateFireBountyJSON(t *testing.T) {
 	}
 }
 
-func Test_removePortFromHost(t *testing.T) {
-	// testURL must be in a variable of type *url.URL, which contains "https://example.com:8080/path?query=123"
-	testURL, _ := url.Parse("https://example.com:8080/path?query=123")
-	value := removePortFromHost(testURL)
-	equals(t, "example.com", value)
-}
-
 func Test_removeDuplicateStr(t *testing.T) {
 	// testSlice must be a slice of strings with duplicates
 	testSlice := []string{"a", "b", "a", "c", "b"}
 	value := removeDuplicateStr(testSlice)
 	equals(t, []string{"a", "b", "c"}, value)
 }
+
+func Test_newTargetResult(t *testing.T) {
+	includes := parseRules([]string{"*.example.com"}, ".inscope")
+	matcher, err := scope.NewMatcher(includes, nil, scope.Options{ExplicitLevel: 2})
+	checkForErrors(t, err)
+
+	target, err := scope.ParseTarget("https://WWW.example.com/login")
+	checkForErrors(t, err)
+	verdict, reason := matcher.ClassifyTarget(target)
+	result := newTargetResult(target, verdict, reason)
+	equals(t, targetResult{
+		Input:    "https://WWW.example.com/login",
+		Host:     "www.example.com",
+		Verdict:  "in",
+		Rule:     "*.example.com",
+		RuleType: "wildcard",
+		Source:   ".inscope",
+	}, result)
+
+	// Unparseable targets only carry the parsing error
+	target, err = scope.ParseTarget("not a target")
+	result = newTargetResult(target, scope.Unparseable, scope.Reason{Err: err})
+	equals(t, "unparseable", result.Verdict)
+	equals(t, "couldn't parse not a target as a valid URL", result.Detail)
+}
+
+func Test_exitCode(t *testing.T) {
+	equals(t, exitInScope, exitCode(nil))
+	equals(t, exitNoneInScope, exitCode(errNoneInScope))
+	equals(t, exitUsage, exitCode(&usageError{"Unable to find the company", errCompanyNotFound}))
+	equals(t, exitDatabase, exitCode(&databaseError{"Couldn't parse firebountyJSON", errors.New("unexpected end of JSON input")}))
+	equals(t, exitIO, exitCode(&ioError{"Could not open targets.txt", os.ErrNotExist}))
+
+	// wrapped errors keep their exit code
+	err := fmt.Errorf("while loading the scopes: %w", &usageError{".inscope does not exist", os.ErrNotExist})
+	equals(t, exitUsage, exitCode(err))
+	equals(t, true, errors.Is(err, os.ErrNotExist))
+}
+
+func Test_loadRulesFile(t *testing.T) {
+	_, _, err := loadRulesFile(filepath.Join(t.TempDir(), ".inscope"))
+	equals(t, exitUsage, exitCode(err))
+
+	path := filepath.Join(t.TempDir(), ".inscope")
+	checkForErrors(t, os.WriteFile(path, []byte("*.example.com\n\nexample.org/api\n"), 0600))
+	rules, negated, err := loadRulesFile(path)
+	checkForErrors(t, err)
+	equals(t, 2, len(rules))
+	equals(t, 0, len(negated))
+	equals(t, 3, rules[1].Line)
+}
+
+func Test_parseScopeLine(t *testing.T) {
+	tests := []struct {
+		text        string
+		raw         string
+		negated     bool
+		empty       bool
+		annotations map[string]string
+	}{
+		{text: "*.example.com", raw: "*.example.com"},
+		{text: "   ", empty: true},
+		{text: "# a comment", empty: true},
+		{text: "  # an indented comment", empty: true},
+		{text: "!admin.example.com", raw: "admin.example.com", negated: true},
+		{text: "! admin.example.com  # legacy panel", raw: "admin.example.com", negated: true},
+		{text: "api.example.com  # max-severity=high owner=team-a", raw: "api.example.com", annotations: map[string]string{"max-severity": "high", "owner": "team-a"}},
+		{text: "api.example.com\t# =ignored", raw: "api.example.com"},
+		// a fragment isn't a comment
+		{text: "https://example.com/#/admin", raw: "https://example.com/#/admin"},
+	}
+
+	for _, test := range tests {
+		line, err := parseScopeLine(test.text)
+		checkForErrors(t, err)
+		equals(t, test.empty, line.empty)
+		equals(t, test.negated, line.negated)
+		equals(t, test.raw, line.rule.Raw)
+		equals(t, test.annotations, line.rule.Annotations)
+	}
+
+	_, err := parseScopeLine("!")
+	assert(t, err != nil, "a lone \"!\" should not be a valid scope")
+}
+
+func Test_readRules(t *testing.T) {
+	setGlobal(t, &chainMode, true)
+	scopes := "# engagement scopes\n" +
+		"*.example.com # max-severity=high\n" +
+		"\n" +
+		"!admin.example.com\n" +
+		"https://[invalid\n" +
+		"192.168.0.0/24\n"
+
+	rules, negated, err := readRules(strings.NewReader(scopes), "scopes.txt", nil)
+	checkForErrors(t, err)
+	equals(t, 2, len(rules))
+	equals(t, "high", rules[0].Annotations["max-severity"])
+	equals(t, 6, rules[1].Line)
+	equals(t, 1, len(negated))
+	equals(t, 4, negated[0].Line)
+	equals(t, "scopes.txt", negated[0].Source)
+}
+
+func Test_loadRulesFiles(t *testing.T) {
+	setGlobal(t, &chainMode, true)
+	directory := t.TempDir()
+	shared := filepath.Join(directory, "shared")
+	checkForErrors(t, os.Mkdir(shared, 0700))
+	checkForErrors(t, os.WriteFile(filepath.Join(shared, "exclusions.scope"), []byte("!admin.example.com\n"), 0600))
+	checkForErrors(t, os.WriteFile(filepath.Join(shared, "extra.scope"), []byte("*.example.net\n"), 0600))
+	checkForErrors(t, os.WriteFile(filepath.Join(directory, "a.scope"), []byte("*.example.com\n@include shared/*.scope\n"), 0600))
+	checkForErrors(t, os.WriteFile(filepath.Join(directory, "b.scope"), []byte("example.org\n"), 0600))
+
+	// the globs match both files of the directory, and the includes are relative to the including file
+	rules, negated, err := loadRulesFiles(filepath.Join(directory, "*.scope"), nil)
+	checkForErrors(t, err)
+	equals(t, 3, len(rules))
+	equals(t, "*.example.com", rules[0].Raw)
+	equals(t, filepath.Join(shared, "extra.scope"), rules[1].Source)
+	equals(t, "example.org", rules[2].Raw)
+	equals(t, 1, len(negated))
+	equals(t, filepath.Join(shared, "exclusions.scope"), negated[0].Source)
+
+	_, _, err = loadRulesFiles(filepath.Join(directory, "*.missing"), nil)
+	equals(t, exitUsage, exitCode(err))
+
+	// include cycles are detected
+	checkForErrors(t, os.WriteFile(filepath.Join(shared, "extra.scope"), []byte("@include ../a.scope\n"), 0600))
+	_, _, err = loadRulesFile(filepath.Join(directory, "a.scope"))
+	equals(t, exitUsage, exitCode(err))
+	assert(t, strings.Contains(err.Error(), "Include cycle"), "expected an include cycle, got %v", err)
+}
+
+func Test_parseCompanyWhitelisted(t *testing.T) {
+	setGlobal(t, &chainMode, true)
+	program := Program{Name: "Example", Slug: "example", source: "firebounty"}
+	program.Scopes.In_scopes = []Scope{{Scope: "*.example.com", Scope_type: "web_application"}}
+	database := Firebounty{
+		White_listed: []WhiteLists{
+			{Regex: "*.example.com", Program_slug: "example"},
+			{Regex: "*.Example-CDN.net", Program_slug: "example"},
+			{Regex: "*.example-cdn.net", Program_slug: "example"},
+			{Regex: "*.other.com", Program_slug: "other"},
+		},
+		Pgms: []Program{program},
+	}
+
+	includes, _, err := parseCompany("example", database, 0)
+	checkForErrors(t, err)
+	equals(t, 1, len(includes))
+
+	// the whitelisted domains that are already in scope, or listed twice, are skipped
+	setGlobal(t, &includeWhitelisted, true)
+	includes, _, err = parseCompany("example", database, 0)
+	checkForErrors(t, err)
+	equals(t, 2, len(includes))
+	equals(t, "*.example-cdn.net", includes[1].Raw)
+	equals(t, "firebounty:white_listed", includes[1].Source)
+	equals(t, "Example", includes[1].Program)
+}
+
+func Test_scopeAssetType(t *testing.T) {
+	tests := []struct {
+		scopeType string
+		asset     scope.AssetType
+		supported bool
+	}{
+		{"web_application", scope.WebAsset, true},
+		{"android_application", scope.AndroidAsset, true},
+		{"mobile-application-ios", scope.IOSAsset, true},
+		{"SOURCE_CODE", scope.RepositoryAsset, true},
+		{"DOWNLOADABLE_EXECUTABLES", scope.ExecutableAsset, true},
+		{"hardware", scope.WebAsset, false},
+		{"other", scope.WebAsset, false},
+	}
+	for _, test := range tests {
+		asset, supported := scopeAssetType(test.scopeType)
+		equals(t, test.asset, asset)
+		equals(t, test.supported, supported)
+	}
+}
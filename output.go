@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+)
+
+// targetResult is the JSON representation of a classified target
+type targetResult struct {
+	Input string `json:"input"`
+	// Host is the normalized host of the target
+	Host string `json:"host,omitempty"`
+	// Type and Identifier are only set for non-web targets (see --target-type)
+	Type       string `json:"type,omitempty"`
+	Identifier string `json:"identifier,omitempty"`
+	Verdict    string `json:"verdict"`
+	// Rule is the scope or noscope rule that decided the verdict
+	Rule     string `json:"rule,omitempty"`
+	RuleType string `json:"rule_type,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Program  string `json:"program,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	// Annotations are the key=value pairs written next to the rule in its scope file
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+func newTargetResult(target scope.Target, verdict scope.Verdict, reason scope.Reason) targetResult {
+	result := targetResult{
+		Input:   target.Input,
+		Host:    target.Host,
+		Verdict: verdict.String(),
+		Detail:  reason.Detail,
+	}
+	if target.Asset != scope.WebAsset {
+		result.Type, result.Identifier = target.Asset.String(), target.Identifier
+	}
+	if reason.Rule != nil {
+		result.Rule = reason.Rule.Raw
+		result.RuleType = reason.Rule.Kind.String()
+		result.Source = reason.Rule.Source
+		result.Program = reason.Rule.Program
+		result.Annotations = reason.Rule.Annotations
+	}
+	if reason.Err != nil {
+		result.Detail = reason.Err.Error()
+	}
+	return result
+}
+
+// resultWriter writes every classified target as JSON (a single array) or JSONL (one object per line)
+type resultWriter struct {
+	format  string
+	file    *os.File
+	encoder *json.Encoder
+	// results are only kept in memory for the "json" format, since the array can't be written until the end
+	results []targetResult
+}
+
+// newResultWriter writes to stdout, and also appends to the output file if one was specified
+func newResultWriter(format string, outputFilepath string) (*resultWriter, error) {
+	w := &resultWriter{format: format}
+
+	var out io.Writer = os.Stdout
+	if outputFilepath != "" {
+		f, err := os.OpenFile(outputFilepath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600) // #nosec G304 -- outputFilepath is a CLI argument specified by the user running the program. It is not unsafe to allow them to open any file in their own system.
+		if err != nil {
+			return nil, err
+		}
+		w.file = f
+		out = io.MultiWriter(os.Stdout, f)
+	}
+	w.encoder = json.NewEncoder(out)
+	return w, nil
+}
+
+func (w *resultWriter) write(result targetResult) error {
+	if w.format == "json" {
+		w.results = append(w.results, result)
+		return nil
+	}
+	return w.encoder.Encode(result)
+}
+
+func (w *resultWriter) close() error {
+	if w.format == "json" {
+		if w.results == nil {
+			w.results = []targetResult{}
+		}
+		if err := w.encoder.Encode(w.results); err != nil {
+			return err
+		}
+	}
+	if w.file != nil {
+		return w.file.Close()
+	}
+	return nil
+}
+
+// Prints the evaluation trace of a target: every rule that was tried, and the rule that decided the verdict
+func printTrace(trace scope.Trace) {
+	fmt.Println(colorBlue + "[+] Explaining: " + colorReset + trace.Target.Input)
+
+	if trace.Verdict == scope.Unparseable {
+		fmt.Println(colorRed + "[-] Verdict: UNPARSEABLE" + colorReset + " (" + trace.Reason.Err.Error() + ")\n")
+		return
+	}
+	if trace.Target.Asset != scope.WebAsset {
+		fmt.Println("    Type: " + trace.Target.Asset.String())
+		fmt.Println("    Identifier: " + trace.Target.Identifier)
+	} else {
+		fmt.Println("    Host: " + trace.Target.Host)
+	}
+	fmt.Println("    Explicit-level: " + strconv.Itoa(trace.ExplicitLevel))
+	fmt.Println("    Policy: " + trace.Policy.String())
+
+	fmt.Println("[+] In-scope rules:")
+	printSteps(trace.Steps, false)
+	fmt.Println("[+] Out-of-scope rules:")
+	printSteps(trace.Steps, true)
+
+	switch trace.Verdict {
+	case scope.InScope:
+		if trace.Reason.Detail != "" {
+			fmt.Println(colorGreen + "[+] Verdict: IN-SCOPE" + colorReset + ", matched by " + describeRule(trace.Reason.Rule) + ". " + trace.Reason.Detail)
+		} else {
+			fmt.Println(colorGreen + "[+] Verdict: IN-SCOPE" + colorReset + ", matched by " + describeRule(trace.Reason.Rule))
+		}
+	case scope.OutOfScope:
+		if trace.Reason.Detail != "" {
+			fmt.Println(colorRed + "[+] Verdict: OUT-OF-SCOPE" + colorReset + ", " + trace.Reason.Detail + " (" + trace.Reason.Rule.Origin() + ")")
+		} else {
+			fmt.Println(colorRed + "[+] Verdict: OUT-OF-SCOPE" + colorReset + ", excluded by " + describeRule(trace.Reason.Rule))
+		}
+	default:
+		fmt.Println(colorYellow + "[+] Verdict: UNSURE" + colorReset + ", no rule matched")
+	}
+	fmt.Println()
+}
+
+func printSteps(steps []scope.Step, exclude bool) {
+	printed := false
+	for _, step := range steps {
+		if step.Exclude != exclude {
+			continue
+		}
+		printed = true
+		if step.Matched {
+			fmt.Println("\t" + colorGreen + "[+] " + colorReset + describeRule(step.Rule) + ": " + step.Detail)
+		} else {
+			fmt.Println("\t[-] " + describeRule(step.Rule) + ": " + step.Detail)
+		}
+	}
+	if !printed {
+		fmt.Println("\t(none)")
+	}
+}
+
+// Warns about the contradicting, shadowed and redundant rules of the scopes
+func printConflicts(conflicts []scope.Conflict) {
+	for _, conflict := range conflicts {
+		warning("Scope " + conflict.Kind.String() + ": " + conflict.Detail + " (" + conflict.Rule.Origin() + " and " + conflict.Other.Origin() + ")")
+	}
+}
+
+// Describes a rule along with its origin, such as `/path/to/.inscope:3 "*.example.com"`
+func describeRule(rule *scope.Rule) string {
+	description := strconv.Quote(rule.Raw)
+	if origin := rule.Origin(); origin != "" {
+		description = origin + " " + description
+	}
+	if len(rule.Annotations) > 0 {
+		annotations := make([]string, 0, len(rule.Annotations))
+		for key, value := range rule.Annotations {
+			annotations = append(annotations, key+"="+value)
+		}
+		sort.Strings(annotations)
+		description += " [" + strings.Join(annotations, " ") + "]"
+	}
+	return description
+}
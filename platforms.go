@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+)
+
+// bug bounty platforms whose scopes can be downloaded, instead of using the firebounty database
+var platforms = []string{"firebounty", "hackerone", "bugcrowd", "intigriti", "yeswehack"}
+
+var platform string
+
+// program handles and slugs are used in file names and URL paths, so they're limited to safe characters
+var programHandleRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Loads the scopes of a program from a bug bounty platform, and returns its in-scope and out-of-scope rules
+func loadPlatformProgram(platform string, handle string) (includes []scope.Rule, excludes []scope.Rule, err error) {
+	if !programHandleRegex.MatchString(handle) {
+		return nil, nil, &usageError{"Invalid " + platform + " program handle \"" + handle + "\"", nil}
+	}
+
+	switch platform {
+	case "hackerone":
+		return loadHackerOneProgram(handle)
+	case "bugcrowd":
+		return loadBugcrowdProgram(handle)
+	case "intigriti":
+		return loadIntigritiProgram(handle)
+	case "yeswehack":
+		return loadYesWeHackProgram(handle)
+	default:
+		return nil, nil, &usageError{"Invalid platform selected: " + platform + ". Use one of: " + strings.Join(platforms, ", "), nil}
+	}
+}
+
+// The scopes downloaded from the platforms are cached next to the firebounty database, one file per program
+func platformCachePath(platform string, handle string) string {
+	return filepath.Join(filepath.Dir(firebountyJSONPath), platform+"-"+strings.ToLower(handle)+".json")
+}
+
+// Reads the cached scopes of a program into v. Returns false if the scopes have to be downloaded again, because the cache is missing or older than 24hs.
+func readPlatformCache(cachePath string, v any) (bool, error) {
+	stats, err := os.Stat(cachePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		// Schrodinger: file may or may not exist. See err for details.
+		return false, &databaseError{"Could not verify existance of the cache \"" + cachePath + "\"", err}
+	}
+
+	//check age. if age > 24hs
+	if stats.ModTime().Before(time.Now().Add(-24 * time.Hour)) {
+		if !chainMode {
+			fmt.Println("[INFO]: +24hs have passed since the last update to \"" + cachePath + "\". Updating...")
+		}
+		return false, nil
+	}
+
+	byteValue, err := os.ReadFile(cachePath) // #nosec G304 -- cachePath is next to the database path, which is a CLI argument specified by the user running the program.
+	if err != nil {
+		return false, &databaseError{"Couldn't read the cache \"" + cachePath + "\"", err}
+	}
+	if err := json.Unmarshal(byteValue, v); err != nil {
+		return false, &databaseError{"Couldn't parse the cache \"" + cachePath + "\"", err}
+	}
+	return true, nil
+}
+
+func writePlatformCache(cachePath string, v any) error {
+	byteValue, err := json.Marshal(v)
+	if err != nil {
+		return &databaseError{"Couldn't encode the scopes", err}
+	}
+	err = os.WriteFile(cachePath, byteValue, 0600)
+	if err != nil {
+		return &databaseError{"Couldn't save the scopes to disk as " + cachePath, err}
+	}
+	return nil
+}
+
+// Sends an authenticated GET request to the API of a platform, and returns the body of the response
+func platformGet(platformName string, requestURL string, handle string, authenticate func(*http.Request)) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, &usageError{"Invalid " + platformName + " URL " + requestURL, err}
+	}
+	authenticate(request)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, &databaseError{"Could not download scopes from " + platformName, err}
+	}
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close() // #nosec G104 -- There is no situation in which closing the body of the request will cause an error.
+	if err != nil {
+		return nil, &databaseError{"Could not download scopes from " + platformName, err}
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, &usageError{platformName + " rejected the request (" + response.Status + "). Check your API credentials, and that you have access to the program \"" + handle + "\"", nil}
+	case http.StatusNotFound:
+		return nil, &usageError{"Unable to find the " + platformName + " program \"" + handle + "\"", errCompanyNotFound}
+	default:
+		return nil, &databaseError{platformName + " answered with an unexpected status: " + response.Status, nil}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+)
+
+// newFixtureServer serves the recorded responses of a platform. A request to "/programs/acme?limit=10" is answered with "testdata/<platform>/programs/acme.json".
+func newFixtureServer(tb testing.TB, platform string, authorized func(*http.Request) bool) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		fixture, err := os.ReadFile(filepath.Join("testdata", platform, filepath.FromSlash(r.URL.Path)+".json"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(fixture)
+	}))
+	tb.Cleanup(server.Close)
+	return server
+}
+
+func hasBearerToken(token string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer "+token
+	}
+}
+
+func Test_loadPlatformProgram(t *testing.T) {
+	_, _, err := loadPlatformProgram("bugcrowd", "../tesla")
+	equals(t, exitUsage, exitCode(err))
+
+	_, _, err = loadPlatformProgram("openbugbounty", "tesla")
+	equals(t, exitUsage, exitCode(err))
+}
+
+func Test_platformCache(t *testing.T) {
+	setGlobal(t, &firebountyJSONPath, filepath.Join(t.TempDir(), firebountyJSONFilename))
+	setGlobal(t, &chainMode, true)
+	cachePath := platformCachePath("yeswehack", "YesWeHack")
+	equals(t, filepath.Join(filepath.Dir(firebountyJSONPath), "yeswehack-yeswehack.json"), cachePath)
+
+	var program yeswehackProgram
+	cached, err := readPlatformCache(cachePath, &program)
+	checkForErrors(t, err)
+	equals(t, false, cached)
+
+	checkForErrors(t, writePlatformCache(cachePath, yeswehackProgram{Title: "YesWeHack"}))
+	cached, err = readPlatformCache(cachePath, &program)
+	checkForErrors(t, err)
+	equals(t, true, cached)
+	equals(t, "YesWeHack", program.Title)
+
+	checkForErrors(t, os.WriteFile(cachePath, []byte("{"), 0600))
+	_, err = readPlatformCache(cachePath, &program)
+	equals(t, exitDatabase, exitCode(err))
+}
+
+func Test_downloadIntigritiProgram(t *testing.T) {
+	server := newFixtureServer(t, "intigriti", hasBearerToken("token"))
+
+	program, err := downloadIntigritiProgram(server.URL, "intigriti", "token")
+	checkForErrors(t, err)
+	equals(t, "Intigriti", program.Name)
+	equals(t, 5, len(program.Domains.Content))
+
+	includes, excludes := intigritiRules("intigriti", program)
+	equals(t, 4, len(includes))
+	equals(t, "*.intigriti.io", includes[1].Raw)
+	equals(t, "203.0.113.0/24", includes[2].Raw)
+	equals(t, "intigriti", includes[0].Source)
+	equals(t, scope.AndroidPackage, includes[3].Kind)
+	equals(t, 1, len(excludes))
+	equals(t, "status.intigriti.io", excludes[0].Raw)
+
+	_, err = downloadIntigritiProgram(server.URL, "unknown", "token")
+	equals(t, true, errors.Is(err, errCompanyNotFound))
+
+	_, err = downloadIntigritiProgram(server.URL, "intigriti", "wrong")
+	equals(t, exitUsage, exitCode(err))
+}
+
+func Test_downloadYesWeHackProgram(t *testing.T) {
+	server := newFixtureServer(t, "yeswehack", hasBearerToken("token"))
+
+	program, err := downloadYesWeHackProgram(server.URL+"/", "yeswehack", "token")
+	checkForErrors(t, err)
+
+	includes, excludes := yeswehackRules("yeswehack", program)
+	equals(t, 4, len(includes))
+	equals(t, "198.51.100.10-198.51.100.20", includes[2].Raw)
+	equals(t, "yeswehack", includes[0].Program)
+	equals(t, "com.yeswehack.android", includes[3].Identifier)
+	// the free text out-of-scopes are skipped
+	equals(t, 1, len(excludes))
+	equals(t, "blog.yeswehack.com", excludes[0].Raw)
+	equals(t, "yeswehack", excludes[0].Source)
+
+	_, err = downloadYesWeHackProgram(server.URL, "unknown", "token")
+	equals(t, true, errors.Is(err, errCompanyNotFound))
+}
@@ -0,0 +1,211 @@
+package scope
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// AssetType is the type of asset of a Target, and the type of asset matched by a Rule. Rules only match targets of their own type.
+type AssetType int
+
+const (
+	// WebAsset is a URL, a hostname or an IP address. It's the default.
+	WebAsset AssetType = iota
+	// AndroidAsset is an Android package name ("com.example.app"), or its Google Play URL
+	AndroidAsset
+	// IOSAsset is an iOS bundle ID ("com.example.app"), or an App Store URL ("https://apps.apple.com/us/app/example/id1234567890")
+	IOSAsset
+	// RepositoryAsset is a source code repository ("https://github.com/example/app")
+	RepositoryAsset
+	// ExecutableAsset is the file name of a downloadable program ("ExampleSetup.exe")
+	ExecutableAsset
+)
+
+// AssetTypes lists every AssetType, in the order of their constants.
+var AssetTypes = []AssetType{WebAsset, AndroidAsset, IOSAsset, RepositoryAsset, ExecutableAsset}
+
+func (a AssetType) String() string {
+	switch a {
+	case WebAsset:
+		return "web"
+	case AndroidAsset:
+		return "android"
+	case IOSAsset:
+		return "ios"
+	case RepositoryAsset:
+		return "repository"
+	case ExecutableAsset:
+		return "executable"
+	}
+	return "unknown"
+}
+
+// ErrInvalidAssetType is returned by ParseAssetType for unknown asset types.
+var ErrInvalidAssetType = errors.New("invalid asset type selected")
+
+// ParseAssetType parses the name of an asset type, such as "android".
+func ParseAssetType(name string) (AssetType, error) {
+	for _, asset := range AssetTypes {
+		if asset.String() == name {
+			return asset, nil
+		}
+	}
+	return WebAsset, fmt.Errorf("%w: %q", ErrInvalidAssetType, name)
+}
+
+// kind is the Kind of the rules of a non-web asset type
+func (a AssetType) kind() Kind {
+	switch a {
+	case AndroidAsset:
+		return AndroidPackage
+	case IOSAsset:
+		return IOSBundle
+	case RepositoryAsset:
+		return Repository
+	}
+	return Executable
+}
+
+// Asset returns the type of asset matched by the rules of this kind.
+func (k Kind) Asset() AssetType {
+	switch k {
+	case AndroidPackage:
+		return AndroidAsset
+	case IOSBundle:
+		return IOSAsset
+	case Repository:
+		return RepositoryAsset
+	case Executable:
+		return ExecutableAsset
+	}
+	return WebAsset
+}
+
+// ParseAssetRule parses a scope of the given asset type. Web scopes are parsed by ParseRule. We may recieve one like the following:
+//
+//	android: com.example.app, com.example.*, https://play.google.com/store/apps/details?id=com.example.app
+//	ios: com.example.app, https://apps.apple.com/us/app/example/id1234567890
+//	repository: https://github.com/example/app, github.com/example/* or github.com/example (every repository of the organization)
+//	executable: ExampleSetup.exe, https://example.com/downloads/ExampleSetup.exe, *.msi
+func ParseAssetRule(scope string, asset AssetType) (Rule, error) {
+	if asset == WebAsset {
+		return ParseRule(scope)
+	}
+	identifier, err := parseIdentifier(scope, asset)
+	if err != nil {
+		return Rule{}, fmt.Errorf("couldn't parse the scope %q: %w", scope, err)
+	}
+
+	rule := Rule{Raw: scope, Kind: asset.kind(), Identifier: identifier}
+	if strings.Contains(identifier, "*") {
+		rule.pattern, err = regexp.Compile("^" + strings.ReplaceAll(regexp.QuoteMeta(identifier), `\*`, ".*") + "$")
+		if err != nil {
+			return Rule{}, fmt.Errorf("couldn't parse the scope %q as a regex: %w", scope, err)
+		}
+	}
+	return rule, nil
+}
+
+// ParseAssetTarget parses a target of the given asset type. Web targets are parsed by ParseTarget.
+func ParseAssetTarget(input string, asset AssetType) (Target, error) {
+	if asset == WebAsset {
+		return ParseTarget(input)
+	}
+	identifier, err := parseIdentifier(input, asset)
+	if err != nil {
+		return Target{Input: input, Asset: asset}, fmt.Errorf("couldn't parse %s: %w", input, err)
+	}
+	return Target{Input: input, Asset: asset, Identifier: identifier}, nil
+}
+
+// parseIdentifier returns the normalized, lowercase'd identifier of a non-web asset
+func parseIdentifier(input string, asset AssetType) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", errors.New("empty " + asset.String() + " asset")
+	}
+
+	switch asset {
+	case AndroidAsset:
+		//Google Play URLs have the package name in the "id" parameter
+		if appURL, err := url.Parse(input); err == nil && appURL.Host != "" {
+			input = appURL.Query().Get("id")
+		}
+		return parseAppID(input, "Android package name")
+
+	case IOSAsset:
+		//App Store URLs don't have the bundle ID, only the App Store ID ("id1234567890")
+		if appURL, err := url.Parse(input); err == nil && appURL.Host != "" {
+			for _, segment := range strings.Split(appURL.Path, "/") {
+				if appStoreIDRegex.MatchString(segment) {
+					return segment, nil
+				}
+			}
+			return "", errors.New("the App Store URL doesn't have an App Store ID")
+		}
+		if appStoreIDRegex.MatchString(input) {
+			return input, nil
+		}
+		return parseAppID(input, "iOS bundle ID")
+
+	case RepositoryAsset:
+		return parseRepository(input)
+	}
+
+	//only the file name of executables is kept ("https://example.com/downloads/ExampleSetup.exe?v=2" -> "examplesetup.exe")
+	if i := strings.IndexAny(input, "?#"); i >= 0 && strings.Contains(input, "://") {
+		input = input[:i]
+	}
+	input = input[strings.LastIndexAny(input, `/\`)+1:]
+	if input == "" {
+		return "", errors.New("missing the file name of the executable")
+	}
+	return strings.ToLower(input), nil
+}
+
+var appStoreIDRegex = regexp.MustCompile(`^id[0-9]+$`)
+
+// appIDRegex matches reverse-DNS identifiers, such as Android package names and iOS bundle IDs. The last label may be a wildcard.
+var appIDRegex = regexp.MustCompile(`^[a-z0-9_-]+(\.[a-z0-9_-]+)*\.([a-z0-9_-]+|\*)$`)
+
+func parseAppID(input string, name string) (string, error) {
+	identifier := strings.ToLower(strings.TrimSpace(input))
+	if !appIDRegex.MatchString(identifier) {
+		return "", fmt.Errorf("%q is not a valid %s", input, name)
+	}
+	return identifier, nil
+}
+
+// parseRepository normalizes a repository to its "host/owner/name" form. We may recieve one like the following:
+//
+//	https://github.com/example/app/tree/main/src
+//	git@github.com:example/app.git
+//	github.com/example
+//	github.com/example/*
+func parseRepository(input string) (string, error) {
+	repository := input
+	if match := schemeRegex.FindString(repository); match != "" {
+		repository = repository[len(match):]
+	} else if user, rest, found := strings.Cut(repository, "@"); found && !strings.Contains(user, "/") {
+		//scp-like syntax ("git@github.com:example/app.git")
+		repository = strings.Replace(rest, ":", "/", 1)
+	}
+	if i := strings.IndexAny(repository, "?#"); i >= 0 {
+		repository = repository[:i]
+	}
+
+	parts := strings.Split(strings.Trim(strings.ToLower(repository), "/"), "/")
+	host := strings.TrimPrefix(parts[0], "www.")
+	if len(parts) < 2 || !strings.Contains(host, ".") || parts[1] == "" {
+		return "", fmt.Errorf("%q is not a valid repository", input)
+	}
+
+	//every repository of an organization
+	if len(parts) == 2 || parts[2] == "" {
+		return host + "/" + parts[1] + "/*", nil
+	}
+	return host + "/" + parts[1] + "/" + strings.TrimSuffix(parts[2], ".git"), nil
+}
@@ -0,0 +1,115 @@
+package scope
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_ParseAssetType(t *testing.T) {
+	for _, asset := range AssetTypes {
+		parsed, err := ParseAssetType(asset.String())
+		checkForErrors(t, err)
+		equals(t, asset, parsed)
+	}
+
+	_, err := ParseAssetType("hardware")
+	equals(t, true, errors.Is(err, ErrInvalidAssetType))
+}
+
+func Test_parseIdentifier(t *testing.T) {
+	tests := []struct {
+		input string
+		asset AssetType
+		exp   string
+	}{
+		{"com.Example.App", AndroidAsset, "com.example.app"},
+		{"https://play.google.com/store/apps/details?id=com.example.app&hl=en", AndroidAsset, "com.example.app"},
+		{"com.example.*", AndroidAsset, "com.example.*"},
+		{"com.example.app", IOSAsset, "com.example.app"},
+		{"https://apps.apple.com/us/app/example/id1234567890", IOSAsset, "id1234567890"},
+		{"https://github.com/Example/App/tree/main/src", RepositoryAsset, "github.com/example/app"},
+		{"git@github.com:example/app.git", RepositoryAsset, "github.com/example/app"},
+		{"https://www.github.com/example", RepositoryAsset, "github.com/example/*"},
+		{"gitlab.com/example/*", RepositoryAsset, "gitlab.com/example/*"},
+		{"https://example.com/downloads/ExampleSetup.exe?v=2", ExecutableAsset, "examplesetup.exe"},
+		{`C:\Program Files\Example\example.exe`, ExecutableAsset, "example.exe"},
+	}
+	for _, test := range tests {
+		identifier, err := parseIdentifier(test.input, test.asset)
+		checkForErrors(t, err)
+		equals(t, test.exp, identifier)
+	}
+
+	for _, test := range []struct {
+		input string
+		asset AssetType
+	}{
+		{"example", AndroidAsset},
+		{"com.example app", AndroidAsset},
+		{"https://apps.apple.com/us/app/example", IOSAsset},
+		{"github.com", RepositoryAsset},
+		{"https://example.com/downloads/", ExecutableAsset},
+	} {
+		if _, err := parseIdentifier(test.input, test.asset); err == nil {
+			t.Errorf("%s %q: expected an error", test.asset, test.input)
+		}
+	}
+}
+
+func Test_assetMatcher(t *testing.T) {
+	var includes, excludes []Rule
+	for _, scope := range []struct {
+		scope string
+		asset AssetType
+	}{
+		{"*.example.com", WebAsset},
+		{"com.example.*", AndroidAsset},
+		{"com.example.app", IOSAsset},
+		{"github.com/example", RepositoryAsset},
+		{"examplesetup.exe", ExecutableAsset},
+	} {
+		rule, err := ParseAssetRule(scope.scope, scope.asset)
+		checkForErrors(t, err)
+		includes = append(includes, rule)
+	}
+	exclude, err := ParseAssetRule("com.example.legacy", AndroidAsset)
+	checkForErrors(t, err)
+	excludes = append(excludes, exclude)
+
+	m, err := NewMatcher(includes, excludes, Options{ExplicitLevel: 2})
+	checkForErrors(t, err)
+
+	tests := []struct {
+		target string
+		asset  AssetType
+		exp    Verdict
+	}{
+		{"com.example.app", AndroidAsset, InScope},
+		{"com.example.legacy", AndroidAsset, OutOfScope},
+		{"com.other.app", AndroidAsset, Unsure},
+		{"com.example.app", IOSAsset, InScope},
+		{"com.example.web", IOSAsset, Unsure},
+		{"https://github.com/example/app.git", RepositoryAsset, InScope},
+		{"https://github.com/other/app", RepositoryAsset, Unsure},
+		{"https://cdn.example.com/ExampleSetup.exe", ExecutableAsset, InScope},
+		// the package names aren't hostnames
+		{"app.example.com", AndroidAsset, Unsure},
+		{"com.example.app", WebAsset, Unsure},
+		{"www.example.com", WebAsset, InScope},
+	}
+	for _, test := range tests {
+		target, err := ParseAssetTarget(test.target, test.asset)
+		checkForErrors(t, err)
+		if verdict, _ := m.ClassifyTarget(target); verdict != test.exp {
+			t.Errorf("%s %q: expected %s, got %s", test.asset, test.target, test.exp, verdict)
+		}
+	}
+
+	trace := m.ExplainAsset("com.example.legacy", AndroidAsset)
+	equals(t, OutOfScope, trace.Verdict)
+	equals(t, "com.example.legacy", trace.Reason.Rule.Raw)
+	equals(t, "the android asset com.example.legacy doesn't match", trace.Steps[0].Detail)
+
+	// the exclusion is covered by the wildcard, so it's not a conflict
+	equals(t, 0, len(m.Conflicts()))
+}
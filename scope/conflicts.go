@@ -0,0 +1,321 @@
+package scope
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Policy decides the verdict of a target that is matched by both an in-scope and an out-of-scope rule.
+type Policy int
+
+const (
+	// ExcludeWins puts the target out of scope. It's the default, and the safest policy.
+	ExcludeWins Policy = iota
+	// MostSpecificWins uses the most specific of both rules, such as "api.example.com" over "*.example.com". Ties are out of scope.
+	MostSpecificWins
+	// IncludeWins puts the target in scope
+	IncludeWins
+)
+
+// Policies lists every Policy, in the order of their constants.
+var Policies = []Policy{ExcludeWins, MostSpecificWins, IncludeWins}
+
+func (p Policy) String() string {
+	switch p {
+	case ExcludeWins:
+		return "exclude-wins"
+	case MostSpecificWins:
+		return "most-specific-wins"
+	case IncludeWins:
+		return "include-wins"
+	}
+	return "unknown"
+}
+
+// ErrInvalidPolicy is returned by ParsePolicy and NewMatcher for unknown policies.
+var ErrInvalidPolicy = errors.New("invalid policy selected")
+
+// ParsePolicy parses the name of a policy, such as "exclude-wins".
+func ParsePolicy(name string) (Policy, error) {
+	for _, policy := range Policies {
+		if policy.String() == name {
+			return policy, nil
+		}
+	}
+	return ExcludeWins, fmt.Errorf("%w: %q", ErrInvalidPolicy, name)
+}
+
+// resolve decides whether the in-scope rule wins over the out-of-scope rule. The returned detail explains the decision.
+func (p Policy) resolve(include *Rule, exclude *Rule) (includeWins bool, detail string) {
+	switch p {
+	case IncludeWins:
+		return true, fmt.Sprintf("the out-of-scope rule %q also matched, but in-scope rules win with the %s policy", exclude.Raw, p)
+	case MostSpecificWins:
+		if compareSpecificity(include, exclude) > 0 {
+			return true, fmt.Sprintf("the out-of-scope rule %q also matched, but it's less specific", exclude.Raw)
+		}
+		return false, fmt.Sprintf("the in-scope rule %q also matched, but it's not more specific", include.Raw)
+	}
+	return false, ""
+}
+
+// compareSpecificity compares two rules that match the same target. It returns a positive number when a is more specific than b, a negative number when b is more specific, and 0 when they're equally specific.
+// The host is compared first, then the path, and then the scheme and ports.
+func compareSpecificity(a *Rule, b *Rule) int {
+	if diff := a.hostSpecificity() - b.hostSpecificity(); diff != 0 {
+		return diff
+	}
+	if diff := len(a.Path) - len(b.Path); diff != 0 {
+		return diff
+	}
+	return a.serviceSpecificity() - b.serviceSpecificity()
+}
+
+// hostSpecificity is the number of fixed bits of an IP rule, or a score based on the number of labels of a hostname rule.
+// Exact hosts are more specific than patterns, and patterns are more specific than wildcards of the same depth.
+func (r *Rule) hostSpecificity() int {
+	switch r.Kind {
+	case Exact:
+		return 2*labelCount(r.Host) + 1
+	case Wildcard:
+		return 2 * labelCount(r.Host)
+	case Pattern:
+		//the labels of the pattern are separated by escaped dots ("^amzn.*\.example\.com$")
+		return 2*(strings.Count(r.pattern.String(), `\.`)+1) - 1
+	case IP:
+		if r.ip.To4() != nil {
+			return 32
+		}
+		return 128
+	case CIDR:
+		ones, _ := r.network.Mask.Size()
+		return ones
+	case IPRange:
+		prefix := commonPrefixLength(r.firstIP, r.lastIP)
+		if r.firstIP.To4() != nil {
+			prefix -= 96
+		}
+		return prefix
+	case AndroidPackage, IOSBundle, Repository, Executable:
+		//a single asset is more specific than a wildcard
+		if r.pattern == nil {
+			return 1
+		}
+	}
+	return 0
+}
+
+func (r *Rule) serviceSpecificity() int {
+	specificity := 0
+	if r.Scheme != "" {
+		specificity++
+	}
+	if !r.Ports.IsZero() {
+		specificity++
+	}
+	return specificity
+}
+
+func labelCount(host string) int {
+	return strings.Count(host, ".") + 1
+}
+
+// commonPrefixLength counts the leading bits shared by both addresses
+func commonPrefixLength(a net.IP, b net.IP) int {
+	for bit := 0; bit < len(a)*8; bit++ {
+		if ipBit(a, bit) != ipBit(b, bit) {
+			return bit
+		}
+	}
+	return len(a) * 8
+}
+
+// ConflictKind is the kind of problem found between the rules of a Matcher.
+type ConflictKind int
+
+const (
+	// Contradiction is a rule listed both as in scope and as out of scope
+	Contradiction ConflictKind = iota
+	// Shadowed is a rule that never decides a verdict, because a rule of the opposite kind always wins over it
+	Shadowed
+	// Redundant is a rule already covered by another rule of the same kind
+	Redundant
+	// Duplicate is a rule listed twice
+	Duplicate
+)
+
+func (k ConflictKind) String() string {
+	switch k {
+	case Contradiction:
+		return "contradiction"
+	case Shadowed:
+		return "shadowed"
+	case Redundant:
+		return "redundant"
+	case Duplicate:
+		return "duplicate"
+	}
+	return "unknown"
+}
+
+// Conflict is a problem between two rules of a Matcher.
+type Conflict struct {
+	Kind ConflictKind
+	// Rule is the rule with the problem
+	Rule *Rule
+	// RuleExclude is set when Rule is an out-of-scope rule
+	RuleExclude bool
+	// Other is the rule that contradicts, shadows or covers Rule
+	Other *Rule
+	// Detail explains the conflict
+	Detail string
+}
+
+// Conflicts analyzes the rules of the Matcher, and reports the rules listed both as in scope and as out of scope, the rules shadowed by a rule of the opposite kind, the redundant wildcards and the duplicates.
+// The rules are analyzed after being adjusted to the explicit-level, and the shadowed rules depend on the policy.
+func (m *Matcher) Conflicts() []Conflict {
+	var conflicts []Conflict
+
+	for i := range m.includes {
+		include := &m.includes[i]
+		for j := range m.excludes {
+			exclude := &m.excludes[j]
+			includeCovered := exclude.covers(include)
+			excludeCovered := include.covers(exclude)
+
+			switch {
+			case includeCovered && excludeCovered:
+				includeWins, _ := m.policy.resolve(include, exclude)
+				winner := "the out-of-scope rule wins"
+				if includeWins {
+					winner = "the in-scope rule wins"
+				}
+				conflicts = append(conflicts, Conflict{Kind: Contradiction, Rule: include, Other: exclude,
+					Detail: fmt.Sprintf("%q is listed both as in scope and as out of scope. With the %s policy, %s", include.Raw, m.policy, winner)})
+			case includeCovered && m.policy == ExcludeWins:
+				conflicts = append(conflicts, Conflict{Kind: Shadowed, Rule: include, Other: exclude,
+					Detail: fmt.Sprintf("the in-scope rule %q is never in scope, because the out-of-scope rule %q covers all of it", include.Raw, exclude.Raw)})
+			case excludeCovered && m.policy == IncludeWins:
+				conflicts = append(conflicts, Conflict{Kind: Shadowed, Rule: exclude, RuleExclude: true, Other: include,
+					Detail: fmt.Sprintf("the out-of-scope rule %q is never used, because the in-scope rule %q covers all of it", exclude.Raw, include.Raw)})
+			}
+		}
+	}
+
+	conflicts = append(conflicts, redundantRules(m.includes, false)...)
+	conflicts = append(conflicts, redundantRules(m.excludes, true)...)
+	return conflicts
+}
+
+// redundantRules finds the duplicated rules, and the wildcards covered by another rule of the same kind
+func redundantRules(rules []Rule, exclude bool) []Conflict {
+	var conflicts []Conflict
+	for i := range rules {
+		rule := &rules[i]
+		for j := range rules {
+			other := &rules[j]
+			if i == j || !other.covers(rule) {
+				continue
+			}
+
+			if rule.covers(other) {
+				//only the latest of the duplicates is reported
+				if j < i {
+					conflicts = append(conflicts, Conflict{Kind: Duplicate, Rule: rule, RuleExclude: exclude, Other: other,
+						Detail: fmt.Sprintf("%q is a duplicate of %q", rule.Raw, other.Raw)})
+					break
+				}
+				continue
+			}
+
+			//wildcards made by the explicit-level are not reported, since they were written as exact hosts
+			if rule.Kind == Wildcard && rule.note == "" {
+				conflicts = append(conflicts, Conflict{Kind: Redundant, Rule: rule, RuleExclude: exclude, Other: other,
+					Detail: fmt.Sprintf("the wildcard %q is redundant, because %q already covers it", rule.Raw, other.Raw)})
+				break
+			}
+		}
+	}
+	return conflicts
+}
+
+// covers reports whether every target matched by other is also matched by the rule. Patterns are only compared with exact hosts, so they may be missed.
+func (r *Rule) covers(other *Rule) bool {
+	return r.coversHost(other) && r.coversPath(other) && r.coversService(other)
+}
+
+func (r *Rule) coversHost(other *Rule) bool {
+	switch r.Kind {
+	case Exact:
+		return other.Kind == Exact && other.Host == r.Host
+	case Wildcard:
+		return (other.Kind == Exact || other.Kind == Wildcard) && isSubdomain(other.Host, r.Host)
+	case Pattern:
+		return (other.Kind == Exact && r.pattern.MatchString(other.Host)) || (other.Kind == Pattern && other.pattern.String() == r.pattern.String())
+	case AndroidPackage, IOSBundle, Repository, Executable:
+		if other.Kind != r.Kind {
+			return false
+		}
+		if r.pattern == nil {
+			return other.pattern == nil && other.Identifier == r.Identifier
+		}
+		return (other.pattern == nil && r.pattern.MatchString(other.Identifier)) || (other.pattern != nil && other.pattern.String() == r.pattern.String())
+	}
+
+	first, last := other.ipBounds()
+	if first == nil {
+		return false
+	}
+	switch r.Kind {
+	case IP:
+		return r.ip.Equal(first) && r.ip.Equal(last)
+	case CIDR:
+		return r.network.Contains(first) && r.network.Contains(last)
+	case IPRange:
+		if (first.To4() == nil) != (r.firstIP.To4() == nil) {
+			return false
+		}
+		return bytes.Compare(first.To16(), r.firstIP) >= 0 && bytes.Compare(last.To16(), r.lastIP) <= 0
+	}
+	return false
+}
+
+// ipBounds returns the first and the last address matched by an IP rule, or nil for hostname rules
+func (r *Rule) ipBounds() (net.IP, net.IP) {
+	switch r.Kind {
+	case IP:
+		return r.ip, r.ip
+	case CIDR:
+		last := make(net.IP, len(r.network.IP))
+		for i := range last {
+			last[i] = r.network.IP[i] | ^r.network.Mask[i]
+		}
+		return r.network.IP, last
+	case IPRange:
+		return r.firstIP, r.lastIP
+	}
+	return nil, nil
+}
+
+func (r *Rule) coversPath(other *Rule) bool {
+	if r.pathPattern == nil || r.Path == other.Path {
+		return true
+	}
+	if other.pathPattern == nil || strings.Contains(r.Path, "*") || strings.Contains(other.Path, "*") {
+		return false
+	}
+	return strings.HasPrefix(other.Path, strings.TrimSuffix(r.Path, "/")+"/")
+}
+
+func (r *Rule) coversService(other *Rule) bool {
+	if r.Scheme != "" && r.Scheme != other.Scheme {
+		return false
+	}
+	if r.Ports.IsZero() {
+		return true
+	}
+	return !other.Ports.IsZero() && other.Ports.From >= r.Ports.From && other.Ports.To <= r.Ports.To
+}
@@ -0,0 +1,153 @@
+package scope
+
+import (
+	"errors"
+	"testing"
+)
+
+// newPolicyMatcher compiles the scopes with explicit-level 2 and the policy, failing the test if any of them can't be parsed.
+func newPolicyMatcher(tb testing.TB, inscopes []string, noscopes []string, policy Policy) *Matcher {
+	includes, errs := ParseRules(inscopes)
+	for _, err := range errs {
+		checkForErrors(tb, err)
+	}
+	excludes, errs := ParseRules(noscopes)
+	for _, err := range errs {
+		checkForErrors(tb, err)
+	}
+	m, err := NewMatcher(includes, excludes, Options{ExplicitLevel: 2, Policy: policy})
+	checkForErrors(tb, err)
+	return m
+}
+
+func Test_ParsePolicy(t *testing.T) {
+	for _, policy := range Policies {
+		parsed, err := ParsePolicy(policy.String())
+		checkForErrors(t, err)
+		equals(t, policy, parsed)
+	}
+
+	_, err := ParsePolicy("first-wins")
+	equals(t, true, errors.Is(err, ErrInvalidPolicy))
+
+	_, err = NewMatcher(nil, nil, Options{ExplicitLevel: 1, Policy: Policy(7)})
+	equals(t, true, errors.Is(err, ErrInvalidPolicy))
+}
+
+func Test_policies(t *testing.T) {
+	inscopes := []string{"*.example.com", "api.example.com", "10.0.0.5", "example.org/api/v2"}
+	noscopes := []string{"*.api.example.com", "admin.example.com", "10.0.0.0/24", "example.org/api"}
+
+	tests := []struct {
+		target string
+		exp    [3]Verdict // exclude-wins, most-specific-wins, include-wins
+	}{
+		// the exact host is more specific than the wildcard
+		{"https://api.example.com", [3]Verdict{OutOfScope, InScope, InScope}},
+		// the deeper wildcard is more specific
+		{"https://v1.api.example.com", [3]Verdict{OutOfScope, OutOfScope, InScope}},
+		// the exact host is more specific than the wildcard
+		{"https://admin.example.com", [3]Verdict{OutOfScope, OutOfScope, InScope}},
+		// the single IP address is more specific than the network
+		{"10.0.0.5", [3]Verdict{OutOfScope, InScope, InScope}},
+		// the longer path is more specific
+		{"https://example.org/api/v2/users", [3]Verdict{OutOfScope, InScope, InScope}},
+		{"https://example.org/api/v1", [3]Verdict{OutOfScope, OutOfScope, OutOfScope}},
+		// no conflict
+		{"https://www.example.com", [3]Verdict{InScope, InScope, InScope}},
+	}
+
+	for i, policy := range Policies {
+		m := newPolicyMatcher(t, inscopes, noscopes, policy)
+		for _, test := range tests {
+			verdict, _ := m.Classify(test.target)
+			if verdict != test.exp[i] {
+				t.Errorf("%s with %s: expected %s, got %s", test.target, policy, test.exp[i], verdict)
+			}
+		}
+	}
+
+	m := newPolicyMatcher(t, inscopes, noscopes, MostSpecificWins)
+	_, reason := m.Classify("https://api.example.com")
+	equals(t, "api.example.com", reason.Rule.Raw)
+	equals(t, `the out-of-scope rule "*.api.example.com" also matched, but it's less specific`, reason.Detail)
+
+	// the most specific rule is searched in every category of rules
+	m = newPolicyMatcher(t, []string{"10.0.0.0/8", "10.1.1.10-10.1.1.20", "*.example.com", "x*.api.example.com"}, []string{"10.1.1.0/24", "*.api.example.com"}, MostSpecificWins)
+	verdict, reason := m.Classify("10.1.1.15")
+	equals(t, InScope, verdict)
+	equals(t, "10.1.1.10-10.1.1.20", reason.Rule.Raw)
+	verdict, reason = m.Classify("https://x1.api.example.com")
+	equals(t, InScope, verdict)
+	equals(t, "x*.api.example.com", reason.Rule.Raw)
+	verdict, _ = m.Classify("https://v1.api.example.com")
+	equals(t, OutOfScope, verdict)
+}
+
+func Test_Conflicts(t *testing.T) {
+	inscopes := []string{"*.example.com", "api.example.com", "*.dev.example.com", "admin.example.com", "192.168.0.10", "*.example.com"}
+	noscopes := []string{"admin.example.com", "*.api.example.com", "192.168.0.0/24", "*.internal.example.com", "*.example.org"}
+
+	m := newPolicyMatcher(t, inscopes, noscopes, ExcludeWins)
+	var found []string
+	for _, conflict := range m.Conflicts() {
+		found = append(found, conflict.Kind.String()+" "+conflict.Rule.Raw+" "+conflict.Other.Raw)
+	}
+	equals(t, []string{
+		"shadowed api.example.com *.api.example.com",
+		"contradiction admin.example.com admin.example.com",
+		"shadowed 192.168.0.10 192.168.0.0/24",
+		"redundant *.dev.example.com *.example.com",
+		"duplicate *.example.com *.example.com",
+	}, found)
+
+	// the out-of-scopes covered by an in-scope are shadowed instead
+	m = newPolicyMatcher(t, []string{"*.example.com"}, []string{"admin.example.com", "*.example.org"}, IncludeWins)
+	conflicts := m.Conflicts()
+	equals(t, 1, len(conflicts))
+	equals(t, Shadowed, conflicts[0].Kind)
+	equals(t, true, conflicts[0].RuleExclude)
+	equals(t, "admin.example.com", conflicts[0].Rule.Raw)
+
+	// the usual carve-outs aren't conflicts
+	m = newPolicyMatcher(t, []string{"*.example.com", "10.0.0.0/8"}, []string{"admin.example.com", "10.0.0.1-10.0.0.9"}, ExcludeWins)
+	equals(t, 0, len(m.Conflicts()))
+}
+
+func Test_covers(t *testing.T) {
+	tests := []struct {
+		rule  string
+		other string
+		exp   bool
+	}{
+		{"*.example.com", "example.com", true},
+		{"*.example.com", "*.api.example.com", true},
+		{"example.com", "*.example.com", false},
+		{"amzn*.example.com", "amzn1.example.com", true},
+		{"*.example.com", "amzn*.example.com", false},
+		{"example.com/api", "example.com/api/v1", true},
+		{"example.com/api", "example.com/apiv1", false},
+		{"example.com/api", "example.com", false},
+		{"https://example.com", "https://example.com/login", true},
+		{"https://example.com", "example.com", false},
+		{"example.com:8000-8100", "example.com:8080", true},
+		{"example.com:8080", "example.com", false},
+		{"10.0.0.0/8", "10.1.0.0/16", true},
+		{"10.0.0.0/16", "10.0.0.0/8", false},
+		{"10.0.0.0/24", "10.0.0.10-20", true},
+		{"10.0.0.10-20", "10.0.0.0/28", false},
+		{"10.0.0.0-10.0.0.255", "10.0.0.0/24", true},
+		{"2001:db8::/32", "2001:db8::1", true},
+		{"2001:db8::/32", "10.0.0.1", false},
+	}
+
+	for _, test := range tests {
+		rule, err := ParseRule(test.rule)
+		checkForErrors(t, err)
+		other, err := ParseRule(test.other)
+		checkForErrors(t, err)
+		if rule.covers(&other) != test.exp {
+			t.Errorf("%q covers %q: expected %v", test.rule, test.other, test.exp)
+		}
+	}
+}
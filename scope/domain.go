@@ -0,0 +1,44 @@
+package scope
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// normalizeHost lowercases the host, removes its trailing dot ("example.com." -> "example.com"), and converts internationalized domain names to punycode
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if !isASCII(host) {
+		//labels are converted one by one, since wildcards aren't valid in domain names
+		labels := strings.Split(host, ".")
+		for i, label := range labels {
+			if ascii, err := idna.Lookup.ToASCII(label); err == nil && !isASCII(label) {
+				labels[i] = ascii
+			}
+		}
+		host = strings.Join(labels, ".")
+	}
+	return host
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// isPublicSuffix reports whether anyone can register domains right under this one, like "com", "co.uk" or "github.io"
+func isPublicSuffix(domain string) bool {
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	return suffix == domain
+}
+
+// isSubdomain reports whether host is domain itself or one of its subdomains, comparing whole labels
+func isSubdomain(host string, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
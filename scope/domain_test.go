@@ -0,0 +1,71 @@
+package scope
+
+import "testing"
+
+// Regression tests for hosts that used to be matched with a raw strings.HasSuffix
+func Test_domainFalsePositives(t *testing.T) {
+	// A wildcard must not match a different domain that merely ends with the same characters
+	m := newTestMatcher(t, []string{"*.example.com"}, nil, 2)
+	equals(t, Unsure, classify(m, "evilexample.com"))
+	equals(t, Unsure, classify(m, "https://www.evilexample.com"))
+	equals(t, InScope, classify(m, "www.example.com"))
+	equals(t, InScope, classify(m, "example.com"))
+
+	// Same for explicit-level 1, where every scope is treated as a wildcard
+	m = newTestMatcher(t, []string{"example.com"}, nil, 1)
+	equals(t, Unsure, classify(m, "notexample.com"))
+	equals(t, InScope, classify(m, "api.example.com"))
+
+	// An out-of-scope wildcard must not silently exclude unrelated domains
+	m = newTestMatcher(t, []string{"notexample.com"}, []string{"*.example.com"}, 2)
+	equals(t, InScope, classify(m, "notexample.com"))
+	equals(t, OutOfScope, classify(m, "www.example.com"))
+
+	// Patterns must match the whole host
+	m = newTestMatcher(t, []string{"amzn*.example.com"}, nil, 2)
+	equals(t, InScope, classify(m, "amzn1.example.com"))
+	equals(t, Unsure, classify(m, "amzn1.example.com.evil.net"))
+	equals(t, Unsure, classify(m, "notamzn1.example.com"))
+
+	// Domains with two top-level-domains
+	m = newTestMatcher(t, []string{"*.example.gov.br"}, nil, 2)
+	equals(t, InScope, classify(m, "portal.example.gov.br"))
+	equals(t, Unsure, classify(m, "example2.gov.br"))
+}
+
+func Test_domainNormalization(t *testing.T) {
+	// Hosts are case-insensitive
+	m := newTestMatcher(t, []string{"*.Example.COM", "API.example.org"}, nil, 2)
+	equals(t, InScope, classify(m, "WWW.EXAMPLE.com"))
+	equals(t, InScope, classify(m, "https://api.EXAMPLE.org/Path"))
+
+	// Trailing dots of fully qualified domain names are ignored
+	m = newTestMatcher(t, []string{"example.com."}, []string{"admin.example.com"}, 2)
+	equals(t, InScope, classify(m, "example.com."))
+	equals(t, InScope, classify(m, "example.com"))
+	equals(t, OutOfScope, classify(m, "admin.example.com."))
+
+	// Internationalized domain names match their punycode form
+	m = newTestMatcher(t, []string{"*.bücher.example"}, nil, 2)
+	equals(t, InScope, classify(m, "www.xn--bcher-kva.example"))
+}
+
+func Test_publicSuffixBoundaries(t *testing.T) {
+	// A wildcard on a public suffix doesn't put every domain under it in scope
+	m := newTestMatcher(t, []string{"*.co.uk", "*.github.io", "com"}, nil, 1)
+	equals(t, Unsure, classify(m, "victim.co.uk"))
+	equals(t, Unsure, classify(m, "someone-else.github.io"))
+	equals(t, Unsure, classify(m, "example.com"))
+	// the public suffix itself isn't put in scope either
+	equals(t, Unsure, classify(m, "co.uk"))
+	equals(t, Unsure, classify(m, "github.io"))
+	trace := m.Explain("victim.co.uk")
+	equals(t, "ignored, because co.uk is a public suffix", trace.Steps[1].Detail)
+
+	// Out-of-scope wildcards are never narrowed down
+	m = newTestMatcher(t, []string{"*.example.co.uk"}, []string{"*.co.uk"}, 2)
+	equals(t, OutOfScope, classify(m, "www.example.co.uk"))
+
+	equals(t, true, isPublicSuffix("co.uk"))
+	equals(t, false, isPublicSuffix("example.co.uk"))
+}
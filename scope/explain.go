@@ -0,0 +1,77 @@
+package scope
+
+import "fmt"
+
+// Step is a single rule tried while explaining a verdict.
+type Step struct {
+	Rule *Rule
+	// Exclude is set for out-of-scope rules
+	Exclude bool
+	Matched bool
+	// Detail explains why the rule did or didn't match
+	Detail string
+}
+
+// Trace is the full evaluation of a target against every rule of a Matcher.
+type Trace struct {
+	Target        Target
+	ExplicitLevel int
+	Policy        Policy
+	// Steps contains every in-scope rule, followed by every out-of-scope rule
+	Steps   []Step
+	Verdict Verdict
+	Reason  Reason
+}
+
+// Explain classifies the target just like Classify does, but also tries every single rule to explain the verdict.
+func (m *Matcher) Explain(target string) Trace {
+	return m.ExplainAsset(target, WebAsset)
+}
+
+// ExplainAsset explains the verdict of a target of the given asset type.
+func (m *Matcher) ExplainAsset(target string, asset AssetType) Trace {
+	trace := Trace{ExplicitLevel: m.explicitLevel, Policy: m.policy}
+
+	var err error
+	trace.Target, err = ParseAssetTarget(target, asset)
+	if err != nil {
+		trace.Verdict, trace.Reason = Unparseable, Reason{Err: err}
+		return trace
+	}
+
+	for i := range m.includes {
+		trace.Steps = append(trace.Steps, explainStep(&m.includes[i], false, trace.Target))
+	}
+	for i := range m.skipped {
+		trace.Steps = append(trace.Steps, Step{Rule: &m.skipped[i], Detail: m.skipped[i].note})
+	}
+	for i := range m.excludes {
+		trace.Steps = append(trace.Steps, explainStep(&m.excludes[i], true, trace.Target))
+	}
+
+	trace.Verdict, trace.Reason = m.ClassifyTarget(trace.Target)
+	return trace
+}
+
+func explainStep(rule *Rule, exclude bool, target Target) Step {
+	step := Step{Rule: rule, Exclude: exclude}
+
+	switch {
+	case !rule.matchHost(target) && target.Asset != WebAsset:
+		step.Detail = fmt.Sprintf("the %s asset %s doesn't match", target.Asset, target.Identifier)
+	case !rule.matchHost(target):
+		step.Detail = fmt.Sprintf("the host %s doesn't match", target.Host)
+	case !rule.matchPath(target):
+		step.Detail = fmt.Sprintf("the path doesn't match %s", rule.Path)
+	case !rule.matchService(target):
+		step.Detail = serviceMismatch(rule, target)
+	default:
+		step.Matched = true
+		step.Detail = "matched"
+	}
+
+	if rule.note != "" {
+		step.Detail += " (" + rule.note + ")"
+	}
+	return step
+}
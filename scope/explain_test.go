@@ -0,0 +1,40 @@
+package scope
+
+import "testing"
+
+func Test_Explain(t *testing.T) {
+	m := newTestMatcher(t, []string{"*.example.com", "api.example.org"}, []string{"admin.example.com", "*.example.com/private"}, 2)
+	m.excludes[0].Source, m.excludes[0].Line = ".noscope", 1
+
+	trace := m.Explain("https://admin.example.com/x")
+	equals(t, OutOfScope, trace.Verdict)
+	equals(t, "admin.example.com", trace.Reason.Rule.Raw)
+	equals(t, ".noscope:1", trace.Reason.Rule.Origin())
+	equals(t, 4, len(trace.Steps))
+
+	// every rule is tried, even after the verdict has been decided
+	equals(t, true, trace.Steps[0].Matched)
+	equals(t, false, trace.Steps[1].Matched)
+	equals(t, "the host admin.example.com doesn't match", trace.Steps[1].Detail)
+	equals(t, true, trace.Steps[2].Exclude)
+	equals(t, true, trace.Steps[2].Matched)
+	equals(t, "the path doesn't match /private", trace.Steps[3].Detail)
+
+	// explain must agree with classify
+	for _, target := range []string{"www.example.com", "example.net", "api.example.org/v1", "https://www.example.com/private/key", "not a url"} {
+		verdict, _ := m.Classify(target)
+		equals(t, verdict, m.Explain(target).Verdict)
+	}
+}
+
+func Test_Explain_skippedWildcards(t *testing.T) {
+	m := newTestMatcher(t, []string{"*.example.com", "www.example.com"}, nil, 3)
+
+	trace := m.Explain("www.example.com")
+	equals(t, InScope, trace.Verdict)
+	equals(t, 2, len(trace.Steps))
+	equals(t, "www.example.com", trace.Steps[0].Rule.Raw)
+	equals(t, "*.example.com", trace.Steps[1].Rule.Raw)
+	equals(t, false, trace.Steps[1].Matched)
+	equals(t, "ignored, because wildcards are not used with explicit-level 3", trace.Steps[1].Detail)
+}
@@ -0,0 +1,239 @@
+package scope
+
+import (
+	"net"
+	"strings"
+)
+
+// ruleIndex finds the rule that matches a target without trying every single rule:
+//   - Exact hosts are kept in a hash set
+//   - Wildcards are kept in a trie of reversed labels (com -> example -> www)
+//   - IP addresses and CIDR ranges are kept in a binary prefix tree
+//   - Non-web rules are kept in a hash set of their identifiers
+//   - Patterns and IP ranges are the only rules tried one by one
+//
+// Several rules may share the same host, since their paths can be different.
+type ruleIndex struct {
+	exact     map[string][]*Rule
+	assets    map[assetKey][]*Rule
+	wildcards *labelNode
+	ipv4      *ipNode
+	ipv6      *ipNode
+	patterns  []*Rule
+	ipRanges  []*Rule
+}
+
+// newRuleIndex indexes the rules. The rules slice must not be modified afterwards.
+func newRuleIndex(rules []Rule) *ruleIndex {
+	idx := &ruleIndex{
+		exact:     make(map[string][]*Rule),
+		assets:    make(map[assetKey][]*Rule),
+		wildcards: &labelNode{},
+		ipv4:      &ipNode{},
+		ipv6:      &ipNode{},
+	}
+	for i := range rules {
+		rule := &rules[i]
+		switch rule.Kind {
+		case Exact:
+			idx.exact[rule.Host] = append(idx.exact[rule.Host], rule)
+		case Wildcard:
+			idx.wildcards.insert(rule.Host, rule)
+		case IP:
+			idx.insertNetwork(rule.ip, len(rule.ip)*8, rule)
+		case CIDR:
+			prefixLength, _ := rule.network.Mask.Size()
+			idx.insertNetwork(rule.network.IP, prefixLength, rule)
+		case IPRange:
+			idx.ipRanges = append(idx.ipRanges, rule)
+		case AndroidPackage, IOSBundle, Repository, Executable:
+			if rule.pattern != nil {
+				idx.patterns = append(idx.patterns, rule)
+			} else {
+				key := assetKey{rule.Kind.Asset(), rule.Identifier}
+				idx.assets[key] = append(idx.assets[key], rule)
+			}
+		default:
+			idx.patterns = append(idx.patterns, rule)
+		}
+	}
+	return idx
+}
+
+// match returns the first rule that matches the target, in the order of find, or nil.
+func (idx *ruleIndex) match(target Target) *Rule {
+	return idx.find(target, func(rule *Rule) bool {
+		return rule.matchURL(target)
+	})
+}
+
+// mostSpecific returns the most specific rule that matches the target, comparing the rules of every category of the index, or nil.
+func (idx *ruleIndex) mostSpecific(target Target) *Rule {
+	var best *Rule
+	//rejecting every rule makes find try every candidate of every category
+	idx.find(target, func(rule *Rule) bool {
+		if rule.matchURL(target) && (best == nil || compareSpecificity(rule, best) > 0) {
+			best = rule
+		}
+		return false
+	})
+	return best
+}
+
+// find returns the first rule whose host matches the target, and that is accepted by the accept function, or nil.
+// The categories are tried in a fixed order: IP networks, IP ranges, exact hosts, wildcards and then patterns. Within the prefix tree and the trie, the most specific network or wildcard is returned.
+func (idx *ruleIndex) find(target Target, accept func(*Rule) bool) *Rule {
+	if target.Asset != WebAsset {
+		if rule := firstAccepted(idx.assets[assetKey{target.Asset, target.Identifier}], accept); rule != nil {
+			return rule
+		}
+		return idx.findPattern(target, accept)
+	}
+
+	if target.IP != nil {
+		if rule := idx.matchIP(target.IP, accept); rule != nil {
+			return rule
+		}
+		for _, rule := range idx.ipRanges {
+			if rule.matchHost(target) && accept(rule) {
+				return rule
+			}
+		}
+	}
+
+	if rule := firstAccepted(idx.exact[target.Host], accept); rule != nil {
+		return rule
+	}
+
+	if rule := idx.wildcards.lookup(target.Host, accept); rule != nil {
+		return rule
+	}
+
+	return idx.findPattern(target, accept)
+}
+
+func (idx *ruleIndex) findPattern(target Target, accept func(*Rule) bool) *Rule {
+	for _, rule := range idx.patterns {
+		if rule.matchHost(target) && accept(rule) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// assetKey is the key of the non-web rules. The same identifier may be used by different asset types, such as an Android package and an iOS bundle ID.
+type assetKey struct {
+	asset      AssetType
+	identifier string
+}
+
+func (idx *ruleIndex) insertNetwork(ip net.IP, prefixLength int, rule *Rule) {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		//IPv4 addresses may come in their 16-byte form, with the prefix length counting the 12-byte IPv6 prefix
+		if len(ip) == net.IPv6len {
+			prefixLength -= 96
+		}
+		idx.ipv4.insert(ipv4, prefixLength, rule)
+	} else {
+		idx.ipv6.insert(ip.To16(), prefixLength, rule)
+	}
+}
+
+// firstAccepted returns the first candidate accepted by the accept function
+func firstAccepted(candidates []*Rule, accept func(*Rule) bool) *Rule {
+	for _, rule := range candidates {
+		if accept(rule) {
+			return rule
+		}
+	}
+	return nil
+}
+
+func (idx *ruleIndex) matchIP(ip net.IP, accept func(*Rule) bool) *Rule {
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return idx.ipv4.lookup(ipv4, accept)
+	}
+	return idx.ipv6.lookup(ip.To16(), accept)
+}
+
+// labelNode is a node of a trie of reversed domain labels.
+type labelNode struct {
+	children map[string]*labelNode
+	// rules are the wildcards covering this domain
+	rules []*Rule
+}
+
+func (n *labelNode) insert(domain string, rule *Rule) {
+	labels := strings.Split(domain, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		if n.children == nil {
+			n.children = make(map[string]*labelNode)
+		}
+		child, found := n.children[labels[i]]
+		if !found {
+			child = &labelNode{}
+			n.children[labels[i]] = child
+		}
+		n = child
+	}
+	n.rules = append(n.rules, rule)
+}
+
+// lookup returns the deepest accepted wildcard that covers the host. The host is walked from its last label to its first one.
+func (n *labelNode) lookup(host string, accept func(*Rule) bool) *Rule {
+	var match *Rule
+	for host != "" {
+		var label string
+		if i := strings.LastIndexByte(host, '.'); i >= 0 {
+			label, host = host[i+1:], host[:i]
+		} else {
+			label, host = host, ""
+		}
+
+		n = n.children[label]
+		if n == nil {
+			break
+		}
+		if rule := firstAccepted(n.rules, accept); rule != nil {
+			match = rule
+		}
+	}
+	return match
+}
+
+// ipNode is a node of a binary prefix tree of IP networks.
+type ipNode struct {
+	children [2]*ipNode
+	// rules are the networks that end at this node
+	rules []*Rule
+}
+
+func (n *ipNode) insert(ip net.IP, prefixLength int, rule *Rule) {
+	for bit := 0; bit < prefixLength; bit++ {
+		b := ipBit(ip, bit)
+		if n.children[b] == nil {
+			n.children[b] = &ipNode{}
+		}
+		n = n.children[b]
+	}
+	n.rules = append(n.rules, rule)
+}
+
+// lookup returns the most specific accepted network that contains the IP
+func (n *ipNode) lookup(ip net.IP, accept func(*Rule) bool) *Rule {
+	match := firstAccepted(n.rules, accept)
+	for bit := 0; bit < len(ip)*8; bit++ {
+		n = n.children[ipBit(ip, bit)]
+		if n == nil {
+			break
+		}
+		if rule := firstAccepted(n.rules, accept); rule != nil {
+			match = rule
+		}
+	}
+	return match
+}
+
+func ipBit(ip net.IP, bit int) byte {
+	return (ip[bit/8] >> (7 - uint(bit%8))) & 1
+}
@@ -0,0 +1,33 @@
+package scope
+
+import "testing"
+
+func Test_ruleIndex(t *testing.T) {
+	rules, _ := ParseRules([]string{"example.com", "*.example.org", "*.dev.example.org", "amzn*.example.net", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.3", "2001:db8::/32"})
+	idx := newRuleIndex(rules)
+
+	for target, expected := range map[string]string{
+		"example.com":              "example.com",
+		"www.example.org":          "*.example.org",
+		"example.org":              "*.example.org",
+		"api.dev.example.org":      "*.dev.example.org",
+		"amzn1.prod.example.net":   "amzn*.example.net",
+		"10.200.2.3":               "10.0.0.0/8",
+		"10.1.200.3":               "10.1.0.0/16",
+		"10.1.2.3":                 "10.1.2.3",
+		"http://[2001:db8::1]:80/": "2001:db8::/32",
+	} {
+		parsedTarget, err := ParseTarget(target)
+		checkForErrors(t, err)
+		rule := idx.match(parsedTarget)
+		if rule == nil {
+			t.Fatalf("%s didn't match any rule", target)
+		}
+		equals(t, expected, rule.Raw)
+	}
+
+	for _, target := range []string{"www.example.com", "wwwexample.org", "11.0.0.1", "org"} {
+		parsedTarget, _ := ParseTarget(target)
+		equals(t, (*Rule)(nil), idx.match(parsedTarget))
+	}
+}
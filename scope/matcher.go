@@ -0,0 +1,186 @@
+// Package scope implements the scope matching engine of hacker-scoper.
+//
+// In-scope and out-of-scope rules are compiled once into a Matcher, which can then classify any number of targets:
+//
+//	includes, _ := scope.ParseRules([]string{"*.example.com", "192.168.0.0/24"})
+//	excludes, _ := scope.ParseRules([]string{"admin.example.com"})
+//	matcher, err := scope.NewMatcher(includes, excludes, scope.Options{ExplicitLevel: 2})
+//	verdict, reason := matcher.Classify("https://www.example.com/login")
+package scope
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Verdict is the result of classifying a target.
+type Verdict int
+
+const (
+	// InScope targets match an in-scope rule, and no out-of-scope rule
+	InScope Verdict = iota
+	// OutOfScope targets match an out-of-scope rule
+	OutOfScope
+	// Unsure targets are not in scope, but are also not out of scope. Very probably unrelated to the bug bounty program.
+	Unsure
+	// Unparseable targets couldn't be parsed as a URL or as an IP address
+	Unparseable
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case InScope:
+		return "in"
+	case OutOfScope:
+		return "out"
+	case Unsure:
+		return "unsure"
+	case Unparseable:
+		return "unparseable"
+	}
+	return "unknown"
+}
+
+// Reason explains a Verdict.
+type Reason struct {
+	// Rule is the rule that decided the verdict. It's nil for Unsure and Unparseable targets.
+	Rule *Rule
+	// Err is the parsing error of Unparseable targets
+	Err error
+	// Detail explains verdicts that aren't obvious from the rule alone, like a port that isn't listed by the rule
+	Detail string
+}
+
+// ErrInvalidExplicitLevel is returned by NewMatcher when Options.ExplicitLevel is not 1, 2 or 3.
+var ErrInvalidExplicitLevel = errors.New("invalid explicit-level selected")
+
+// Options control how in-scope rules are interpreted. Out-of-scope rules are always parsed as explicit-level 2.
+type Options struct {
+	// ExplicitLevel is how explicit we expect the scopes to be:
+	//  1: Include subdomains in the scope even if there's not a wildcard in the scope
+	//  2: Include subdomains in the scope only if there's a wildcard in the scope
+	//  3: Include subdomains in the scope only if they are explicitly within the scope
+	ExplicitLevel int
+	// Policy decides the verdict of targets matched by both an in-scope and an out-of-scope rule. The zero value is ExcludeWins.
+	Policy Policy
+}
+
+// Matcher is a compiled set of in-scope and out-of-scope rules. It's safe for concurrent use.
+type Matcher struct {
+	includes []Rule
+	excludes []Rule
+	// skipped are the in-scope rules ignored because of the explicit-level
+	skipped       []Rule
+	explicitLevel int
+	policy        Policy
+
+	includeIndex *ruleIndex
+	excludeIndex *ruleIndex
+}
+
+// NewMatcher compiles the in-scope and out-of-scope rules into a Matcher.
+func NewMatcher(includes []Rule, excludes []Rule, opts Options) (*Matcher, error) {
+	if opts.ExplicitLevel < 1 || opts.ExplicitLevel > 3 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidExplicitLevel, opts.ExplicitLevel)
+	}
+
+	if opts.Policy < ExcludeWins || opts.Policy > IncludeWins {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidPolicy, opts.Policy)
+	}
+
+	m := &Matcher{explicitLevel: opts.ExplicitLevel, policy: opts.Policy}
+	for _, rule := range excludes {
+		//out-of-scopes exclude the host on every scheme. "mongodb://example.com" still excludes https://example.com
+		rule.Scheme = ""
+		rule.exclude = true
+		m.excludes = append(m.excludes, rule)
+	}
+	for _, rule := range includes {
+		switch {
+		case rule.Kind == Wildcard && opts.ExplicitLevel == 3:
+			//subdomains are only in scope if they're explicitly listed
+			rule.note = "ignored, because wildcards are not used with explicit-level 3"
+			m.skipped = append(m.skipped, rule)
+			continue
+		case rule.Kind == Wildcard && isPublicSuffix(rule.Host):
+			//wildcards don't cross public suffix boundaries. "*.co.uk" doesn't put every .co.uk domain in scope, nor co.uk itself
+			rule.note = "ignored, because " + rule.Host + " is a public suffix"
+			m.skipped = append(m.skipped, rule)
+			continue
+		case rule.Kind == Exact && opts.ExplicitLevel == 1 && !isPublicSuffix(rule.Host):
+			//this is NOT a wildcard domain, but we'll treat it as such anyway
+			rule.Kind = Wildcard
+			rule.note = "treated as a wildcard, because of explicit-level 1"
+		}
+		m.includes = append(m.includes, rule)
+	}
+	m.includeIndex = newRuleIndex(m.includes)
+	m.excludeIndex = newRuleIndex(m.excludes)
+	return m, nil
+}
+
+// ParseRules parses every scope string. Scopes that can't be parsed are skipped, and their errors are returned.
+func ParseRules(scopes []string) ([]Rule, []error) {
+	var rules []Rule
+	var errs []error
+	for _, s := range scopes {
+		rule, err := ParseRule(s)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, errs
+}
+
+// Classify parses the target and classifies it. When both an out-of-scope and an in-scope rule match, the Policy of the Matcher decides the verdict.
+func (m *Matcher) Classify(target string) (Verdict, Reason) {
+	t, err := ParseTarget(target)
+	if err != nil {
+		return Unparseable, Reason{Err: err}
+	}
+	return m.ClassifyTarget(t)
+}
+
+// ClassifyTarget classifies an already parsed target.
+func (m *Matcher) ClassifyTarget(target Target) (Verdict, Reason) {
+	var exclude, include *Rule
+	if m.policy == MostSpecificWins {
+		//a more specific rule may be in a category tried after the first match
+		exclude = m.excludeIndex.mostSpecific(target)
+		include = m.includeIndex.mostSpecific(target)
+	} else {
+		exclude = m.excludeIndex.match(target)
+		include = m.includeIndex.match(target)
+	}
+	if exclude != nil && include != nil {
+		includeWins, detail := m.policy.resolve(include, exclude)
+		if includeWins {
+			return InScope, Reason{Rule: include, Detail: detail}
+		}
+		return OutOfScope, Reason{Rule: exclude, Detail: detail}
+	}
+	if exclude != nil {
+		return OutOfScope, Reason{Rule: exclude}
+	}
+	if include != nil {
+		return InScope, Reason{Rule: include}
+	}
+
+	//the host is in scope, but not on this scheme or port
+	rule := m.includeIndex.find(target, func(rule *Rule) bool {
+		return rule.matchPath(target) && !rule.matchService(target)
+	})
+	if rule != nil {
+		return OutOfScope, Reason{Rule: rule, Detail: serviceMismatch(rule, target)}
+	}
+	return Unsure, Reason{}
+}
+
+func serviceMismatch(rule *Rule, target Target) string {
+	if rule.Scheme != "" && target.Scheme != "" && rule.Scheme != target.Scheme {
+		return fmt.Sprintf("the scheme %s is not listed by the scope %q", target.Scheme, rule.Raw)
+	}
+	return fmt.Sprintf("the port %d is not listed by the scope %q", target.Port, rule.Raw)
+}
@@ -0,0 +1,270 @@
+package scope
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+//========================================================================
+//                            HELPER FUNCTIONS
+//========================================================================
+
+// checkForErrors fails the test if an err is not nil.
+func checkForErrors(tb testing.TB, err error) {
+	if err != nil {
+		_, file, line, _ := runtime.Caller(1)
+		fmt.Printf("\033[31m%s:%d: unexpected error: %s\033[39m\n\n", filepath.Base(file), line, err.Error())
+		tb.FailNow()
+	}
+}
+
+// equals fails the test if exp is not equal to act.
+func equals(tb testing.TB, exp, act interface{}) {
+	if !reflect.DeepEqual(exp, act) {
+		_, file, line, _ := runtime.Caller(1)
+		fmt.Printf("\033[31m%s:%d:\n\n\texp: %#v\n\n\tgot: %#v\033[39m\n\n", filepath.Base(file), line, exp, act)
+		tb.FailNow()
+	}
+}
+
+// newTestMatcher compiles the scopes, failing the test if any of them can't be parsed.
+func newTestMatcher(tb testing.TB, inscopes []string, noscopes []string, explicitLevel int) *Matcher {
+	includes, errs := ParseRules(inscopes)
+	for _, err := range errs {
+		checkForErrors(tb, err)
+	}
+	excludes, errs := ParseRules(noscopes)
+	for _, err := range errs {
+		checkForErrors(tb, err)
+	}
+	m, err := NewMatcher(includes, excludes, Options{ExplicitLevel: explicitLevel})
+	checkForErrors(tb, err)
+	return m
+}
+
+// classify returns only the verdict for the target.
+func classify(m *Matcher, target string) Verdict {
+	verdict, _ := m.Classify(target)
+	return verdict
+}
+
+//========================================================================
+//========================================================================
+//========================================================================
+
+func Test_outOfScopes(t *testing.T) {
+	// Simple test - inscope URL
+	m := newTestMatcher(t, nil, []string{"zendesk*.example.com"}, 2)
+	equals(t, Unsure, classify(m, "https://example.com"))
+
+	// Simple test - out of scope URL
+	equals(t, OutOfScope, classify(m, "https://zendesk.internal.example.com"))
+
+	// Simple test - in-scope URL with a URL-like out-of-scope string
+	m = newTestMatcher(t, nil, []string{"https://sometool.internal.example.com"}, 2)
+	equals(t, Unsure, classify(m, "https://zendesk.internal.example.com"))
+
+	// Simple test - out-of-scope URL with a URL-like out-of-scope string
+	m = newTestMatcher(t, nil, []string{"https://zendesk.internal.example.com"}, 2)
+	equals(t, OutOfScope, classify(m, "https://zendesk.internal.example.com"))
+
+	// Test - in-scope URL with a URL-like out-of-scope string with an unusual scheme
+	m = newTestMatcher(t, nil, []string{"mongodb://sometool.internal.example.com"}, 2)
+	equals(t, Unsure, classify(m, "https://zendesk.internal.example.com"))
+
+	// Test - out-of-scope URL with a URL-like out-of-scope string with an unusual scheme
+	m = newTestMatcher(t, nil, []string{"mongodb://zendesk.internal.example.com"}, 2)
+	equals(t, OutOfScope, classify(m, "https://zendesk.internal.example.com"))
+
+	// Test - out-of-scope wildcard
+	m = newTestMatcher(t, nil, []string{"*.example.com"}, 2)
+	equals(t, OutOfScope, classify(m, "https://zendesk.internal.example.com"))
+
+	// Test - out-of-scope IP address
+	m = newTestMatcher(t, nil, []string{"127.0.0.1"}, 2)
+	equals(t, OutOfScope, classify(m, "127.0.0.1"))
+	equals(t, Unsure, classify(m, "127.0.0.2"))
+}
+
+func Test_exclusionWins(t *testing.T) {
+	m := newTestMatcher(t, []string{"*.example.com"}, []string{"admin.example.com"}, 2)
+	equals(t, InScope, classify(m, "https://www.example.com"))
+	equals(t, OutOfScope, classify(m, "https://admin.example.com"))
+
+	verdict, reason := m.Classify("https://admin.example.com/login")
+	equals(t, OutOfScope, verdict)
+	equals(t, "admin.example.com", reason.Rule.Raw)
+}
+
+func Test_explicitLevels(t *testing.T) {
+	inscopes := []string{"example.com", "*.wild.example.org"}
+
+	// 1: Include subdomains in the scope even if there's not a wildcard in the scope
+	m := newTestMatcher(t, inscopes, nil, 1)
+	equals(t, InScope, classify(m, "sub.example.com"))
+	equals(t, InScope, classify(m, "sub.wild.example.org"))
+
+	// 2: Include subdomains in the scope only if there's a wildcard in the scope
+	m = newTestMatcher(t, inscopes, nil, 2)
+	equals(t, Unsure, classify(m, "sub.example.com"))
+	equals(t, InScope, classify(m, "example.com"))
+	equals(t, InScope, classify(m, "sub.wild.example.org"))
+
+	// 3: Include subdomains in the scope only if they are explicitly within the scope
+	m = newTestMatcher(t, inscopes, nil, 3)
+	equals(t, InScope, classify(m, "example.com"))
+	equals(t, Unsure, classify(m, "sub.wild.example.org"))
+
+	_, err := NewMatcher(nil, nil, Options{ExplicitLevel: 4})
+	equals(t, true, err != nil)
+}
+
+func Test_ipScopes(t *testing.T) {
+	m := newTestMatcher(t, []string{"192.168.1.10", "10.0.0.0/24"}, nil, 2)
+	equals(t, InScope, classify(m, "192.168.1.10"))
+	equals(t, InScope, classify(m, "http://10.0.0.55:8080/"))
+	equals(t, Unsure, classify(m, "10.0.1.1"))
+	equals(t, Unsure, classify(m, "example.com"))
+}
+
+func Test_unparseableTargets(t *testing.T) {
+	m := newTestMatcher(t, []string{"example.com"}, nil, 2)
+	verdict, reason := m.Classify("this is not a url")
+	equals(t, Unparseable, verdict)
+	equals(t, true, reason.Err != nil)
+}
+
+func Benchmark_Classify(b *testing.B) {
+	// A 300-rule program, which is about as big as bug bounty programs get
+	var inscopes []string
+	var noscopes []string
+	for i := 0; i < 100; i++ {
+		inscopes = append(inscopes, fmt.Sprintf("*.app%d.example.com", i))
+		inscopes = append(inscopes, fmt.Sprintf("api%d.example.org", i))
+		noscopes = append(noscopes, fmt.Sprintf("admin.app%d.example.com", i))
+	}
+	m := newTestMatcher(b, inscopes, noscopes, 2)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Classify(fmt.Sprintf("https://www%d.app%d.example.com/login", i, i%150))
+	}
+}
+
+func Test_pathScopes(t *testing.T) {
+	m := newTestMatcher(t, []string{"example.com/api/*", "*.example.org"}, []string{"www.example.org/admin", "*.example.org/internal/*/debug", "192.168.0.1/admin"}, 2)
+
+	// A target is in scope only when both its host and its path match
+	equals(t, InScope, classify(m, "https://example.com/api"))
+	equals(t, InScope, classify(m, "https://example.com/api/v1/users?id=1"))
+	equals(t, Unsure, classify(m, "https://example.com/"))
+	equals(t, Unsure, classify(m, "example.com"))
+	equals(t, Unsure, classify(m, "https://example.com/apiv2"))
+
+	// Path exclusions on an otherwise in-scope host
+	equals(t, InScope, classify(m, "https://www.example.org/"))
+	equals(t, OutOfScope, classify(m, "https://www.example.org/admin"))
+	equals(t, OutOfScope, classify(m, "https://www.example.org/admin/users"))
+	equals(t, InScope, classify(m, "https://www.example.org/administrator"))
+	equals(t, OutOfScope, classify(m, "https://api.example.org/internal/v2/debug"))
+	equals(t, InScope, classify(m, "https://api.example.org/internal/v2"))
+
+	// The target paths are cleaned before being matched
+	equals(t, InScope, classify(m, "https://www.example.org/admin/../users"))
+	equals(t, OutOfScope, classify(m, "https://www.example.org/users/../admin"))
+	equals(t, OutOfScope, classify(m, "https://www.example.org//admin"))
+
+	// Paths work on IP addresses too
+	equals(t, OutOfScope, classify(m, "http://192.168.0.1/admin/"))
+	equals(t, Unsure, classify(m, "http://192.168.0.1/"))
+
+	verdict, reason := m.Classify("https://www.example.org/admin")
+	equals(t, OutOfScope, verdict)
+	equals(t, "/admin", reason.Rule.Path)
+}
+
+func Test_portAndSchemeScopes(t *testing.T) {
+	m := newTestMatcher(t, []string{"https://api.example.com:8443", "example.com:8000-8100", "*.example.org"}, []string{"www.example.org:8080"}, 2)
+
+	// Pinned port and scheme
+	equals(t, InScope, classify(m, "https://api.example.com:8443/v1"))
+	equals(t, InScope, classify(m, "api.example.com:8443"))
+	equals(t, OutOfScope, classify(m, "http://api.example.com:8443"))
+	equals(t, OutOfScope, classify(m, "https://api.example.com"))
+	equals(t, OutOfScope, classify(m, "https://api.example.com:9000/admin"))
+
+	// Port ranges
+	equals(t, InScope, classify(m, "http://example.com:8000"))
+	equals(t, InScope, classify(m, "example.com:8100"))
+	equals(t, OutOfScope, classify(m, "example.com:8101"))
+
+	// Targets without a port can't be ruled out
+	equals(t, InScope, classify(m, "example.com"))
+
+	// Out-of-scope ports
+	equals(t, InScope, classify(m, "https://www.example.org"))
+	equals(t, OutOfScope, classify(m, "http://www.example.org:8080"))
+	equals(t, OutOfScope, classify(m, "www.example.org:8080"))
+	// targets with an unknown port aren't excluded by a port-pinned out-of-scope
+	equals(t, InScope, classify(m, "www.example.org"))
+	equals(t, InScope, classify(m, "www.example.org:443"))
+
+	verdict, reason := m.Classify("https://api.example.com:9000")
+	equals(t, OutOfScope, verdict)
+	equals(t, "https://api.example.com:8443", reason.Rule.Raw)
+	equals(t, `the port 9000 is not listed by the scope "https://api.example.com:8443"`, reason.Detail)
+}
+
+func Test_ipOutOfScopes(t *testing.T) {
+	m := newTestMatcher(t, []string{"10.0.0.0/8", "2001:db8::/32"}, []string{"10.0.0.0/16", "10.1.1.10-10.1.1.50", "2001:db8:dead::/48", "2001:db8::10-2001:db8::20"}, 2)
+
+	// CIDR exclusions carve subnets out of a scoped range
+	equals(t, OutOfScope, classify(m, "10.0.200.1"))
+	equals(t, InScope, classify(m, "10.1.0.1"))
+
+	// Dash-style ranges are inclusive
+	equals(t, InScope, classify(m, "10.1.1.9"))
+	equals(t, OutOfScope, classify(m, "10.1.1.10"))
+	equals(t, OutOfScope, classify(m, "http://10.1.1.30:8080/"))
+	equals(t, OutOfScope, classify(m, "10.1.1.50"))
+	equals(t, InScope, classify(m, "10.1.1.51"))
+
+	// IPv6 prefixes and ranges
+	ipv6Target := func(ip string) Verdict {
+		verdict, _ := m.ClassifyTarget(Target{Input: ip, Host: ip, IP: net.ParseIP(ip)})
+		return verdict
+	}
+	equals(t, InScope, ipv6Target("2001:db8::1"))
+	equals(t, OutOfScope, ipv6Target("2001:db8:dead::1"))
+	equals(t, OutOfScope, ipv6Target("2001:db8::15"))
+	equals(t, InScope, ipv6Target("2001:db8::21"))
+
+	// An IPv4 range never matches IPv6 addresses, even if their last 32 bits are inside of it (::10.1.1.30)
+	equals(t, Unsure, ipv6Target("::a01:11e"))
+}
+
+func Test_ipv6Scopes(t *testing.T) {
+	m := newTestMatcher(t, []string{"2001:db8::/32", "[2001:db8:1::1]:8443", "203.0.113.10-203.0.113.80", "198.51.100.10-20", "FE80::0202:B3FF:FE1E:8329"}, []string{"2001:db8:dead::/48"}, 2)
+
+	// Bare and bracketed IPv6 targets
+	equals(t, InScope, classify(m, "2001:db8::1"))
+	equals(t, InScope, classify(m, "[2001:db8::1]:8443"))
+	equals(t, InScope, classify(m, "https://[2001:db8::1]:8443/login"))
+	equals(t, OutOfScope, classify(m, "http://[2001:db8:dead::1]/"))
+	equals(t, InScope, classify(m, "fe80::202:b3ff:fe1e:8329"))
+	equals(t, Unsure, classify(m, "2001:db9::1"))
+
+	// Bracketed IPv6 scopes keep their port
+	equals(t, InScope, classify(m, "https://[2001:db8:1::1]:8443"))
+
+	// Hyphenated IPv4 ranges, and their /24 shorthand
+	equals(t, InScope, classify(m, "203.0.113.10"))
+	equals(t, InScope, classify(m, "203.0.113.80"))
+	equals(t, Unsure, classify(m, "203.0.113.81"))
+	equals(t, InScope, classify(m, "198.51.100.15"))
+	equals(t, Unsure, classify(m, "198.51.100.21"))
+}
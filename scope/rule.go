@@ -0,0 +1,413 @@
+package scope
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind tells how a Rule is matched against a target.
+type Kind int
+
+const (
+	// Exact matches a single hostname ("example.com")
+	Exact Kind = iota
+	// Wildcard matches a domain and all of its subdomains ("*.example.com")
+	Wildcard
+	// Pattern is a scope with a wildcard anywhere else, matched as a regex ("amzn*.example.com")
+	Pattern
+	// IP matches a single IP address ("192.168.0.1")
+	IP
+	// CIDR matches every IP address inside of a network ("192.168.0.0/24")
+	CIDR
+	// IPRange matches every IP address between two addresses ("192.168.1.10-192.168.1.50")
+	IPRange
+	// AndroidPackage matches an Android package name ("com.example.app"). See ParseAssetRule.
+	AndroidPackage
+	// IOSBundle matches an iOS bundle ID or App Store ID
+	IOSBundle
+	// Repository matches a source code repository ("github.com/example/app"), or every repository of an organization
+	Repository
+	// Executable matches the file name of a downloadable program ("examplesetup.exe")
+	Executable
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Exact:
+		return "exact"
+	case Wildcard:
+		return "wildcard"
+	case Pattern:
+		return "pattern"
+	case IP:
+		return "ip"
+	case CIDR:
+		return "cidr"
+	case IPRange:
+		return "iprange"
+	case AndroidPackage:
+		return "android-package"
+	case IOSBundle:
+		return "ios-bundle"
+	case Repository:
+		return "repository"
+	case Executable:
+		return "executable"
+	}
+	return "unknown"
+}
+
+// Rule is a single parsed scope or out-of-scope entry.
+type Rule struct {
+	// Raw is the rule exactly as it was written
+	Raw  string
+	Kind Kind
+	// Host is the hostname of an Exact rule, or the parent domain of a Wildcard rule
+	Host string
+	// Path is the path prefix or glob the target URL must match. Empty means every path.
+	Path string
+	// Scheme is the scheme the target URL must use. Empty means every scheme.
+	Scheme string
+	// Ports is the range of ports the target URL must use. The zero value means every port.
+	Ports PortRange
+	// Identifier is the normalized package name, bundle ID, repository or file name of a non-web rule. It may have wildcards.
+	Identifier string
+
+	// Source is where the rule came from, such as the path of a scopes file. It's not set by ParseRule.
+	Source string
+	// Program is the name of the bug bounty program that published the rule. It's not set by ParseRule.
+	Program string
+	// Line is the line number of the rule inside of its Source, starting at 1. It's 0 when unknown.
+	Line int
+	// Annotations are the key=value pairs written next to the rule in its scope file, such as "max-severity=high". They're not set by ParseRule.
+	Annotations map[string]string
+
+	// note explains how the Matcher adjusted the rule, such as turning it into a wildcard because of the explicit-level
+	note string
+	// exclude is set by the Matcher on out-of-scope rules
+	exclude bool
+
+	pattern     *regexp.Regexp
+	pathPattern *regexp.Regexp
+	ip          net.IP
+	network     *net.IPNet
+	// first and last addresses of an IPRange rule, in their 16-byte form
+	firstIP net.IP
+	lastIP  net.IP
+}
+
+// Origin describes where the rule came from ("/path/to/.inscope:3", or "firebounty (Tesla)").
+func (r *Rule) Origin() string {
+	origin := r.Source
+	if r.Line > 0 {
+		origin += ":" + strconv.Itoa(r.Line)
+	}
+	if r.Program != "" {
+		origin += " (" + r.Program + ")"
+	}
+	return origin
+}
+
+// PortRange is an inclusive range of ports, such as 8000-8100. The zero value matches every port.
+type PortRange struct {
+	From int
+	To   int
+}
+
+// IsZero reports whether the range matches every port.
+func (p PortRange) IsZero() bool {
+	return p.From == 0 && p.To == 0
+}
+
+// Contains reports whether the port is inside of the range.
+func (p PortRange) Contains(port int) bool {
+	return p.IsZero() || (port >= p.From && port <= p.To)
+}
+
+func (p PortRange) String() string {
+	if p.From == p.To {
+		return strconv.Itoa(p.From)
+	}
+	return strconv.Itoa(p.From) + "-" + strconv.Itoa(p.To)
+}
+
+var schemeRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://`)
+
+// ParseRule parses a scope string. We may recieve one like the following:
+//
+//	example.com
+//	*.example.com
+//	amzn*.example.com
+//	https://example.com
+//	mongodb://example.com
+//	192.168.0.1
+//	192.168.0.1/24
+//	192.168.1.10-192.168.1.50
+//	192.168.1.10-50
+//	2001:db8::/32
+//	[2001:db8::1]:8443
+//	example.com/api/*
+//	https://api.example.com:8443
+//	example.com:8000-8100
+func ParseRule(scope string) (Rule, error) {
+	raw := scope
+	scope = strings.TrimSpace(scope)
+	if scope == "" {
+		return Rule{}, errors.New("empty scope")
+	}
+
+	//attempt to parse the scope as an IP address or as a CIDR range
+	if ip := net.ParseIP(scope); ip != nil {
+		return Rule{Raw: raw, Kind: IP, ip: ip}, nil
+	}
+	if _, network, err := net.ParseCIDR(scope); err == nil {
+		return Rule{Raw: raw, Kind: CIDR, network: network}, nil
+	}
+	if first, last, found := strings.Cut(scope, "-"); found && net.ParseIP(first) != nil {
+		firstIP, lastIP, err := parseIPRange(first, last)
+		if err != nil {
+			return Rule{}, fmt.Errorf("couldn't parse the scope %q as an IP range: %w", raw, err)
+		}
+		return Rule{Raw: raw, Kind: IPRange, firstIP: firstIP, lastIP: lastIP}, nil
+	}
+
+	var scheme string
+	if match := schemeRegex.FindString(scope); match != "" {
+		scheme = strings.ToLower(strings.TrimSuffix(match, "://"))
+		scope = scope[len(match):]
+	}
+
+	hostPart, pathPart := splitHostPath(scope)
+	hostPart, ports, err := splitPort(hostPart)
+	if err != nil {
+		return Rule{}, fmt.Errorf("couldn't parse the port of the scope %q: %w", raw, err)
+	}
+	host, err := parseHost(hostPart)
+	if err != nil {
+		return Rule{}, fmt.Errorf("couldn't parse the scope %q as a valid URL", raw)
+	}
+
+	rule := Rule{Raw: raw, Host: host, Scheme: scheme, Ports: ports}
+	switch {
+	//if the scope starts with a single wildcard, it covers every subdomain
+	case strings.HasPrefix(host, "*.") && strings.Count(host, "*") == 1:
+		rule.Kind = Wildcard
+		rule.Host = strings.TrimPrefix(host, "*.")
+
+	//if the scope is in a weird wildcard format, we parse it as a regex
+	case strings.Contains(host, "*"):
+		rule.Kind = Pattern
+		rule.Host = ""
+		//the pattern must match the whole host, so "amzn*.example.com" doesn't match "amzn.example.com.evil.net"
+		rule.pattern, err = regexp.Compile("^" + strings.ReplaceAll(regexp.QuoteMeta(host), `\*`, ".*") + "$")
+		if err != nil {
+			return Rule{}, fmt.Errorf("couldn't parse the scope %q as a regex: %w", raw, err)
+		}
+
+	case net.ParseIP(host) != nil:
+		rule.Kind = IP
+		rule.ip = net.ParseIP(host)
+		rule.Host = ""
+
+	default:
+		rule.Kind = Exact
+	}
+
+	if err := rule.setPath(pathPart); err != nil {
+		return Rule{}, fmt.Errorf("couldn't parse the path of the scope %q: %w", raw, err)
+	}
+	return rule, nil
+}
+
+// parseIPRange parses both ends of a range of IP addresses
+func parseIPRange(first string, last string) (net.IP, net.IP, error) {
+	firstIP := net.ParseIP(first)
+
+	//shorthand for IPv4 ranges inside of the same /24 ("203.0.113.10-80")
+	if firstIP.To4() != nil && !strings.ContainsAny(last, ".:") {
+		last = first[:strings.LastIndexByte(first, '.')+1] + last
+	}
+
+	lastIP := net.ParseIP(last)
+	if lastIP == nil {
+		return nil, nil, fmt.Errorf("invalid IP address %q", last)
+	}
+	if (firstIP.To4() == nil) != (lastIP.To4() == nil) {
+		return nil, nil, errors.New("the range mixes IPv4 and IPv6 addresses")
+	}
+	if bytes.Compare(firstIP.To16(), lastIP.To16()) > 0 {
+		return nil, nil, errors.New("the first address of the range is greater than the last one")
+	}
+	return firstIP.To16(), lastIP.To16(), nil
+}
+
+// splitHostPath splits a scheme-less scope into its host and its path. Queries and fragments are discarded.
+func splitHostPath(scope string) (host string, path string) {
+	if i := strings.IndexAny(scope, "?#"); i >= 0 {
+		scope = scope[:i]
+	}
+	if i := strings.IndexByte(scope, '/'); i >= 0 {
+		return scope[:i], scope[i:]
+	}
+	return scope, ""
+}
+
+// splitPort removes the port or port range from the host ("example.com:8000-8100" -> "example.com", 8000-8100)
+func splitPort(host string) (string, PortRange, error) {
+	var port string
+	if strings.HasPrefix(host, "[") {
+		//IPv6 address ("[2001:db8::1]:8443")
+		if end := strings.IndexByte(host, ']'); end >= 0 && strings.HasPrefix(host[end+1:], ":") {
+			host, port = host[:end+1], host[end+2:]
+		}
+	} else if strings.Count(host, ":") == 1 {
+		host, port, _ = strings.Cut(host, ":")
+	}
+	if port == "" {
+		return host, PortRange{}, nil
+	}
+
+	ports, err := ParsePortRange(port)
+	return host, ports, err
+}
+
+// ParsePortRange parses a single port ("443") or a range of ports ("8000-8100").
+func ParsePortRange(s string) (PortRange, error) {
+	from, to, isRange := strings.Cut(s, "-")
+	if !isRange {
+		to = from
+	}
+
+	var ports PortRange
+	var err error
+	if ports.From, err = strconv.Atoi(from); err != nil || ports.From < 1 || ports.From > 65535 {
+		return PortRange{}, fmt.Errorf("invalid port %q", from)
+	}
+	if ports.To, err = strconv.Atoi(to); err != nil || ports.To < 1 || ports.To > 65535 {
+		return PortRange{}, fmt.Errorf("invalid port %q", to)
+	}
+	if ports.To < ports.From {
+		return PortRange{}, fmt.Errorf("invalid port range %q", s)
+	}
+	return ports, nil
+}
+
+// parseHost returns the normalized, portless host of a scheme-less scope
+func parseHost(scope string) (string, error) {
+	//bare IPv6 addresses need brackets before being parsed as URLs
+	if ip := net.ParseIP(scope); ip != nil && strings.Contains(scope, ":") {
+		scope = "[" + scope + "]"
+	}
+
+	scopeURL, err := url.Parse("https://" + scope)
+	if err != nil {
+		return "", err
+	}
+	if scopeURL.Host == "" {
+		return "", errors.New("missing host")
+	}
+	return normalizeHost(removePortFromHost(scopeURL)), nil
+}
+
+// setPath compiles the path of the rule. We may recieve one like the following:
+//
+//	(empty) or "/": every path
+//	/admin: "/admin" and everything under it, but not "/administrator"
+//	/api/*: same as "/api"
+//	/api/*/internal: a glob. The wildcard matches anything, slashes included
+func (r *Rule) setPath(path string) error {
+	path = strings.TrimSuffix(path, "/*")
+	if path == "" || path == "/" {
+		return nil
+	}
+
+	unescapedPath, err := url.PathUnescape(path)
+	if err != nil {
+		return err
+	}
+	r.Path = path
+
+	var expression string
+	if strings.Contains(unescapedPath, "*") {
+		expression = "^" + strings.ReplaceAll(regexp.QuoteMeta(unescapedPath), `\*`, ".*") + "$"
+	} else {
+		expression = "^" + regexp.QuoteMeta(strings.TrimSuffix(unescapedPath, "/")) + "(/.*)?$"
+	}
+	r.pathPattern, err = regexp.Compile(expression)
+	return err
+}
+
+// matchURL checks the parts of the target URL that aren't its host
+func (r *Rule) matchURL(target Target) bool {
+	return r.matchPath(target) && r.matchService(target)
+}
+
+// matchService checks the scheme and port of the target. Targets with an unknown scheme or port are not rejected by in-scope rules,
+// but they're not excluded either: "!example.com:8080" must not exclude "example.com", which is very probably https://example.com:443
+func (r *Rule) matchService(target Target) bool {
+	if r.exclude && ((r.Scheme != "" && target.Scheme == "") || (!r.Ports.IsZero() && target.Port == 0)) {
+		return false
+	}
+	if r.Scheme != "" && target.Scheme != "" && r.Scheme != target.Scheme {
+		return false
+	}
+	if target.Port != 0 && !r.Ports.Contains(target.Port) {
+		return false
+	}
+	return true
+}
+
+func (r *Rule) matchPath(target Target) bool {
+	if r.pathPattern == nil {
+		return true
+	}
+
+	path := "/"
+	if target.URL != nil && target.URL.Path != "" {
+		path = target.URL.Path
+	}
+	return r.pathPattern.MatchString(path)
+}
+
+// Match reports whether the target is covered by the rule.
+func (r *Rule) Match(target Target) bool {
+	return r.matchHost(target) && r.matchURL(target)
+}
+
+func (r *Rule) matchHost(target Target) bool {
+	if r.Kind.Asset() != target.Asset {
+		return false
+	}
+	switch r.Kind {
+	case Exact:
+		return target.Host == r.Host
+	case Wildcard:
+		//if x is y, or a subdomain of y
+		//ex: wordpress.example.com with a scope of *.example.com will give a match, but wordpressexample.com won't
+		return isSubdomain(target.Host, r.Host)
+	case Pattern:
+		return r.pattern.MatchString(target.Host)
+	case IP:
+		return target.IP != nil && target.IP.Equal(r.ip)
+	case CIDR:
+		return target.IP != nil && r.network.Contains(target.IP)
+	case IPRange:
+		if target.IP == nil || (target.IP.To4() == nil) != (r.firstIP.To4() == nil) {
+			return false
+		}
+		ip := target.IP.To16()
+		return bytes.Compare(ip, r.firstIP) >= 0 && bytes.Compare(ip, r.lastIP) <= 0
+	case AndroidPackage, IOSBundle, Repository, Executable:
+		if r.pattern != nil {
+			return r.pattern.MatchString(target.Identifier)
+		}
+		return target.Identifier == r.Identifier
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package scope
+
+import (
+	"fmt"
+	"testing"
+)
+
+func Test_ParseRule(t *testing.T) {
+	rule, err := ParseRule("example.com")
+	checkForErrors(t, err)
+	equals(t, Exact, rule.Kind)
+	equals(t, "example.com", rule.Host)
+
+	rule, err = ParseRule("*.example.com")
+	checkForErrors(t, err)
+	equals(t, Wildcard, rule.Kind)
+	equals(t, "example.com", rule.Host)
+
+	rule, err = ParseRule("amzn*.example.com")
+	checkForErrors(t, err)
+	equals(t, Pattern, rule.Kind)
+
+	rule, err = ParseRule("https://example.com:8080/path")
+	checkForErrors(t, err)
+	equals(t, Exact, rule.Kind)
+	equals(t, "example.com", rule.Host)
+
+	rule, err = ParseRule("192.168.0.1")
+	checkForErrors(t, err)
+	equals(t, IP, rule.Kind)
+
+	rule, err = ParseRule("192.168.0.1/24")
+	checkForErrors(t, err)
+	equals(t, CIDR, rule.Kind)
+
+	rule, err = ParseRule("https://*.example.com/api/*")
+	checkForErrors(t, err)
+	equals(t, Wildcard, rule.Kind)
+	equals(t, "example.com", rule.Host)
+	equals(t, "/api", rule.Path)
+
+	rule, err = ParseRule("192.168.0.1/admin")
+	checkForErrors(t, err)
+	equals(t, IP, rule.Kind)
+	equals(t, "/admin", rule.Path)
+
+	rule, err = ParseRule("HTTPS://api.example.com:8000-8100")
+	checkForErrors(t, err)
+	equals(t, "https", rule.Scheme)
+	equals(t, PortRange{8000, 8100}, rule.Ports)
+	equals(t, "api.example.com", rule.Host)
+
+	_, err = ParseRule("example.com:8100-8000")
+	equals(t, true, err != nil)
+
+	_, err = ParseRule("example.com:99999")
+	equals(t, true, err != nil)
+
+	rule, err = ParseRule("192.168.1.10-192.168.1.50")
+	checkForErrors(t, err)
+	equals(t, IPRange, rule.Kind)
+
+	_, err = ParseRule("192.168.1.50-192.168.1.10")
+	equals(t, true, err != nil)
+
+	_, err = ParseRule("192.168.1.10-2001:db8::1")
+	equals(t, true, err != nil)
+
+	_, err = ParseRule("   ")
+	equals(t, true, err != nil)
+}
+
+func ExampleParseRule() {
+	// Test with an invalid scope string
+	_, err := ParseRule("this is not even close to a URL")
+
+	fmt.Println(err)
+	// Output: couldn't parse the scope "this is not even close to a URL" as a valid URL
+}
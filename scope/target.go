@@ -0,0 +1,106 @@
+package scope
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Target is a parsed recon target, such as a URL, a hostname or an IP address.
+type Target struct {
+	// Input is the target exactly as it was supplied
+	Input string
+	URL   *url.URL
+	// Host is the portless, lowercase'd host of the target, without a trailing dot
+	Host string
+	// IP is set when the host is an IP address
+	IP net.IP
+	// Scheme is the lowercase'd scheme of the target. It's empty when the target didn't have one.
+	Scheme string
+	// Port is the port of the target, or the default port of its scheme. It's 0 when unknown.
+	Port int
+	// Asset is the type of asset of the target. Only WebAsset targets have a URL and a Host.
+	Asset AssetType
+	// Identifier is the normalized package name, bundle ID, repository or file name of a non-web target
+	Identifier string
+}
+
+// default ports of the most common schemes
+var defaultPorts = map[string]int{
+	"http":  80,
+	"https": 443,
+	"ws":    80,
+	"wss":   443,
+	"ftp":   21,
+	"ssh":   22,
+}
+
+// ParseTarget parses a target line. Targets without a scheme are parsed as "https://" URLs.
+func ParseTarget(input string) (Target, error) {
+	rawInput := input
+
+	//bare IPv6 addresses need brackets before being parsed as URLs ("2001:db8::1" -> "https://[2001:db8::1]")
+	if ip := net.ParseIP(input); ip != nil && strings.Contains(input, ":") {
+		input = "[" + input + "]"
+	}
+
+	targetURL, err := url.Parse(input)
+	hasScheme := true
+
+	//If we couldn't parse it as is, attempt to add the "https://" prefix
+	if err != nil || targetURL.Host == "" {
+		targetURL, err = url.Parse("https://" + input)
+		hasScheme = false
+	}
+	if err != nil || targetURL.Host == "" {
+		return Target{Input: rawInput}, fmt.Errorf("couldn't parse %s as a valid URL", rawInput)
+	}
+
+	//the path is cleaned, so "/api/admin/../x" can't be confused with "/api/admin"
+	if targetURL.Path != "" {
+		cleanPath := path.Clean(targetURL.Path)
+		if strings.HasSuffix(targetURL.Path, "/") && cleanPath != "/" {
+			cleanPath += "/"
+		}
+		targetURL.Path, targetURL.RawPath = cleanPath, ""
+	}
+
+	host := normalizeHost(removePortFromHost(targetURL))
+	target := Target{
+		Input: rawInput,
+		URL:   targetURL,
+		Host:  host,
+		IP:    net.ParseIP(host),
+	}
+	if hasScheme {
+		target.Scheme = strings.ToLower(targetURL.Scheme)
+	}
+	if port := targetURL.Port(); port != "" {
+		target.Port, _ = strconv.Atoi(port)
+	} else {
+		target.Port = defaultPorts[target.Scheme]
+	}
+	return target, nil
+}
+
+// Hostname returns the host of the target without decorations. IP addresses are returned in their canonical form, and non-web targets return their identifier.
+func (t Target) Hostname() string {
+	if t.Asset != WebAsset {
+		return t.Identifier
+	}
+	if t.IP != nil {
+		return t.IP.String()
+	}
+	if t.URL == nil {
+		return ""
+	}
+	return t.URL.Hostname()
+}
+
+// removePortFromHost returns the host without its port. IPv6 addresses are returned without brackets ("[2001:db8::1]:8443" -> "2001:db8::1")
+func removePortFromHost(url *url.URL) string {
+	return url.Hostname()
+}
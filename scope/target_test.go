@@ -0,0 +1,59 @@
+package scope
+
+import (
+	"net/url"
+	"testing"
+)
+
+func Test_removePortFromHost(t *testing.T) {
+	// testURL must be in a variable of type *url.URL, which contains "https://example.com:8080/path?query=123"
+	testURL, _ := url.Parse("https://example.com:8080/path?query=123")
+	value := removePortFromHost(testURL)
+	equals(t, "example.com", value)
+
+	// IPv6 addresses must not be mangled
+	testURL, _ = url.Parse("https://[2001:db8::1]:8443/path")
+	value = removePortFromHost(testURL)
+	equals(t, "2001:db8::1", value)
+}
+
+func Test_ParseTarget(t *testing.T) {
+	target, err := ParseTarget("example.com:8080/login")
+	checkForErrors(t, err)
+	equals(t, "example.com", target.Host)
+	equals(t, "example.com", target.Hostname())
+	equals(t, true, target.IP == nil)
+
+	target, err = ParseTarget("redis://192.168.0.1:6379")
+	checkForErrors(t, err)
+	equals(t, "192.168.0.1", target.Hostname())
+	equals(t, false, target.IP == nil)
+
+	target, err = ParseTarget("HTTP://example.com/")
+	checkForErrors(t, err)
+	equals(t, "http", target.Scheme)
+	equals(t, 80, target.Port)
+
+	target, err = ParseTarget("example.com/api/admin/../x/")
+	checkForErrors(t, err)
+	equals(t, "/api/x/", target.URL.Path)
+	equals(t, "example.com/api/admin/../x/", target.Input)
+
+	target, err = ParseTarget("example.com")
+	checkForErrors(t, err)
+	equals(t, "", target.Scheme)
+	equals(t, 0, target.Port)
+
+	target, err = ParseTarget("2001:DB8::1")
+	checkForErrors(t, err)
+	equals(t, "2001:DB8::1", target.Input)
+	equals(t, "2001:db8::1", target.Hostname())
+
+	target, err = ParseTarget("[2001:db8::1]:8443")
+	checkForErrors(t, err)
+	equals(t, "2001:db8::1", target.Host)
+	equals(t, 8443, target.Port)
+
+	_, err = ParseTarget("")
+	equals(t, true, err != nil)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Scores of the company search. The fuzzy matches always score below the substring matches, so they're only used when nothing else matched.
+const (
+	scoreExact      = 100
+	scorePrefix     = 90
+	scoreWordPrefix = 80
+	scoreSubstring  = 70
+	scoreFuzzy      = 60
+)
+
+// The fields of a program that are searched, from the most to the least important. Matches on the less important fields lose a point per position.
+var searchFields = []struct {
+	name  string
+	value func(program Program) string
+}{
+	{"name", func(program Program) string { return program.Name }},
+	{"slug", func(program Program) string { return program.Slug }},
+	{"tag", func(program Program) string { return program.Tag }},
+	{"domain", func(program Program) string { return programDomain(program.Url) }},
+}
+
+// Scores how well the program matches the company, from 0 (no match) to 100. The field that matched best is also returned.
+// The search is case and accent insensitive, and tolerates a typo every 4 characters (up to 2).
+func searchScore(company string, program Program) (int, string) {
+	query := compactSearchText(company)
+	if query == "" {
+		return 0, ""
+	}
+
+	bestScore, bestField := 0, ""
+	for penalty, field := range searchFields {
+		value := field.value(program)
+		if value == "" {
+			continue
+		}
+		if score := fieldScore(query, normalizeSearchText(value)); score > 0 && score-penalty > bestScore {
+			bestScore, bestField = score-penalty, field.name
+		}
+	}
+	return bestScore, bestField
+}
+
+// Scores a single normalized field against the compacted query
+func fieldScore(query string, field string) int {
+	words := strings.Fields(field)
+	compact := strings.Join(words, "")
+
+	switch {
+	case compact == query:
+		return scoreExact
+	case strings.HasPrefix(compact, query):
+		return scorePrefix
+	}
+	for _, word := range words {
+		if strings.HasPrefix(word, query) {
+			return scoreWordPrefix
+		}
+	}
+	if strings.Contains(compact, query) {
+		return scoreSubstring
+	}
+
+	//typos are only tolerated on queries long enough to not match everything
+	allowedTypos := min(len([]rune(query))/4, 2)
+	if allowedTypos == 0 {
+		return 0
+	}
+	distance := editDistance(query, compact)
+	for _, word := range words {
+		distance = min(distance, editDistance(query, word))
+	}
+	if distance > allowedTypos {
+		return 0
+	}
+	return scoreFuzzy - 10*(distance-1)
+}
+
+// Returns the host of the program URL without "www.", such as "tesla.com"
+func programDomain(programURL string) string {
+	parsed, err := url.Parse(programURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// Lowercases the text, removes its accents, and replaces everything that isn't a letter or a digit with spaces ("Société Générale" -> "societe generale")
+func normalizeSearchText(text string) string {
+	var normalized strings.Builder
+	//the decomposed letters are followed by their accents, which are combining marks ("é" -> "e" + "́")
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			normalized.WriteRune(r)
+		} else {
+			normalized.WriteRune(' ')
+		}
+	}
+	return normalized.String()
+}
+
+// Same as normalizeSearchText, but without spaces ("Yes We Hack" -> "yeswehack")
+func compactSearchText(text string) string {
+	return strings.Join(strings.Fields(normalizeSearchText(text)), "")
+}
+
+// editDistance counts the insertions, deletions, substitutions and transpositions of adjacent characters needed to turn a into b (optimal string alignment distance)
+func editDistance(a string, b string) int {
+	source, target := []rune(a), []rune(b)
+	//only the last 3 rows of the matrix are needed
+	previous2 := make([]int, len(target)+1)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && source[i-1] == target[j-2] && source[i-2] == target[j-1] {
+				current[j] = min(current[j], previous2[j-2]+1)
+			}
+		}
+		previous2, previous, current = previous, current, previous2
+	}
+	return previous[len(target)]
+}
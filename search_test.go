@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func Test_searchScore(t *testing.T) {
+	program := Program{Name: "Société Générale", Slug: "socgen", Tag: "banking", Url: "https://www.societegenerale.com/en/responsible-disclosure"}
+
+	tests := []struct {
+		company string
+		score   int
+		field   string
+	}{
+		{"societe generale", scoreExact, "name"},
+		{"SOCIÉTÉ", scorePrefix, "name"},
+		{"generale", scoreWordPrefix, "name"},
+		{"nerale", scoreSubstring, "name"},
+		{"socgen", scoreExact - 1, "slug"},
+		{"bank", scorePrefix - 2, "tag"},
+		{"societegenerale.com", scoreExact - 3, "domain"},
+		// typos
+		{"generael", scoreFuzzy, "name"},
+		{"sociteegeneral", scoreFuzzy - 10, "name"},
+		{"genrl", 0, ""},
+		{"xyz", 0, ""},
+		{"", 0, ""},
+	}
+	for _, test := range tests {
+		score, field := searchScore(test.company, program)
+		if score != test.score || field != test.field {
+			t.Errorf("%q: expected %d (%s), got %d (%s)", test.company, test.score, test.field, score, field)
+		}
+	}
+}
+
+func Test_normalizeSearchText(t *testing.T) {
+	equals(t, "societe generale", normalizeSearchText("Société Générale"))
+	// the accents of every precomposed letter are removed
+	equals(t, "ha long tiet erdos", normalizeSearchText("Hạ Long Tiết Erdős"))
+	// decomposed accents too
+	equals(t, "societe", normalizeSearchText("socie\u0301te\u0301"))
+
+	score, field := searchScore("erdos", Program{Name: "Erdős Labs"})
+	equals(t, scorePrefix, score)
+	equals(t, "name", field)
+}
+
+func Test_editDistance(t *testing.T) {
+	equals(t, 0, editDistance("tesla", "tesla"))
+	equals(t, 1, editDistance("tsela", "tesla"))
+	equals(t, 1, editDistance("tesl", "tesla"))
+	equals(t, 2, editDistance("gogle", "googl"))
+	equals(t, 5, editDistance("", "tesla"))
+}
+
+func Test_selectCompaniesRanked(t *testing.T) {
+	setGlobal(t, &chainMode, true)
+	database, err := loadBountyTargetsData(filepath.Join("testdata", "bounty-targets-data"))
+	checkForErrors(t, err)
+
+	// the query is searched in the slug and domain too, and isn't case sensitive
+	selected, err := selectCompanies("Yes We Hack", database)
+	checkForErrors(t, err)
+	equals(t, []int{3}, selected)
+
+	// the best match is ranked first
+	setGlobal(t, &selection, programSelection{index: 0})
+	selected, err = selectCompanies("a", database)
+	checkForErrors(t, err)
+	equals(t, []int{0}, selected)
+
+	// the typos are only suggested
+	setGlobal(t, &selection, programSelection{index: -1})
+	_, err = selectCompanies("tsela", database)
+	equals(t, true, errors.Is(err, errCompanyNotFound))
+	equals(t, "Unable to find the company \"tsela\". Did you mean: 0 - Tesla (score 60, name)? Pick them with --program-index or --all-matches: no company matched the search", err.Error())
+
+	setGlobal(t, &selection, programSelection{index: 0})
+	selected, err = selectCompanies("tsela", database)
+	checkForErrors(t, err)
+	equals(t, []int{1}, selected)
+}
@@ -0,0 +1,542 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+)
+
+// ScopeSource is anything that the in-scope and out-of-scope rules of a program can be loaded from: the firebounty database, the API of a platform, scope files, URLs...
+// Every rule returned by Load must have its Source (and Program, if known) set, so that the verdicts can be traced back to their source after merging several sources.
+type ScopeSource interface {
+	// Name describes the source in messages, such as "company:google" or "inscope-file:scopes.txt"
+	Name() string
+	Load() (includes []scope.Rule, excludes []scope.Rule, err error)
+}
+
+// kinds of sources that can be specified with --source kind:value
+var sourceKinds = []string{".inscope", "company", "hackerone", "bugcrowd", "intigriti", "yeswehack", "inscope-file", "outofscope-file", "url"}
+
+// stringList is the value of the repeatable flags, such as --source and --inscope-file
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+var sourceSpecs stringList
+
+var mergeParentScopes bool
+
+// Parses a --source argument, such as "company:google", "hackerone:security" or "url:https://example.com/scopes.txt"
+func parseSource(spec string, databaseFolder string) (ScopeSource, error) {
+	if spec == ".inscope" {
+		return dotScopeSource{mergeParents: mergeParentScopes}, nil
+	}
+
+	kind, value, found := strings.Cut(spec, ":")
+	if !found || value == "" {
+		return nil, &usageError{"Invalid source \"" + spec + "\". Use kind:value, where kind is one of: " + strings.Join(sourceKinds, ", "), nil}
+	}
+
+	switch kind {
+	case "company":
+		return companySource{company: strings.ToLower(value), databaseFolder: databaseFolder}, nil
+	case "hackerone", "bugcrowd", "intigriti", "yeswehack":
+		return platformSource{platform: kind, handle: value}, nil
+	case "inscope-file":
+		return scopeFileSource{inscopePath: value}, nil
+	case "outofscope-file":
+		return scopeFileSource{noscopePath: value}, nil
+	case "url":
+		return urlSource{url: value}, nil
+	default:
+		return nil, &usageError{"Invalid source kind \"" + kind + "\". Use one of: " + strings.Join(sourceKinds, ", "), nil}
+	}
+}
+
+// Builds the list of sources from the --source arguments and the legacy --company, --inscope-file and --outofscope-file arguments.
+// If no source was specified, the .inscope and .noscope files are used.
+func scopeSources(specs []string, company string, scopesListFilepaths []string, outofScopesListFilepaths []string, databaseFolder string) ([]ScopeSource, error) {
+	var sources []ScopeSource
+
+	for _, spec := range specs {
+		source, err := parseSource(spec, databaseFolder)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+
+	//the programs of the database can also be searched with --program-slug, --program-url or --name-regex alone
+	if company != "" || (selection.hasFilters() && platform == "firebounty") {
+		var source ScopeSource
+		if platform != "firebounty" {
+			source = platformSource{platform: platform, handle: company}
+		} else {
+			source = companySource{company: strings.ToLower(company), databaseFolder: databaseFolder}
+		}
+
+		//the user's own out-of-scopes files take precedence over the out-of-scopes of the company
+		if len(outofScopesListFilepaths) > 0 {
+			source = outOfScopesFileOverride{ScopeSource: source, paths: outofScopesListFilepaths}
+		}
+		sources = append(sources, source)
+	} else if len(scopesListFilepaths) > 0 || (len(outofScopesListFilepaths) > 0 && len(sources) > 0) {
+		for _, path := range scopesListFilepaths {
+			sources = append(sources, scopeFileSource{inscopePath: path})
+		}
+		for _, path := range outofScopesListFilepaths {
+			sources = append(sources, scopeFileSource{noscopePath: path})
+		}
+	}
+
+	if len(sources) == 0 {
+		if !chainMode {
+			fmt.Print("No company or scopes file specified. Looking for a \".inscope\" file..." + "\n")
+		}
+		sources = append(sources, dotScopeSource{mergeParents: mergeParentScopes})
+	}
+
+	return sources, nil
+}
+
+// Reports whether the user can be asked to pick a program
+func stdinIsTerminal() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// Loads every source, and merges their rules into a single rule set. The provenance of every rule is kept in the rule itself.
+func loadSources(sources []ScopeSource) (includes []scope.Rule, excludes []scope.Rule, err error) {
+	for _, source := range sources {
+		sourceIncludes, sourceExcludes, err := source.Load()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(sources) > 1 && !chainMode {
+			fmt.Println("[+] Loaded " + strconv.Itoa(len(sourceIncludes)) + " in-scope and " + strconv.Itoa(len(sourceExcludes)) + " out-of-scope rules from " + source.Name())
+		}
+		includes = append(includes, sourceIncludes...)
+		excludes = append(excludes, sourceExcludes...)
+	}
+	return includes, excludes, nil
+}
+
+// dotScopeSource is the .inscope file, and the optional .noscope file, of the current directory or any of its parents
+type dotScopeSource struct {
+	// mergeParents merges every .inscope and .noscope file up to the filesystem root, instead of using only the nearest ones
+	mergeParents bool
+}
+
+func (dotScopeSource) Name() string {
+	return ".inscope"
+}
+
+func (s dotScopeSource) Load() (includes []scope.Rule, excludes []scope.Rule, err error) {
+	if s.mergeParents {
+		return loadParentScopes()
+	}
+
+	//look for .inscope file
+	inscopePath, err := searchForFileBackwards(".inscope")
+	if err != nil {
+		return nil, nil, &usageError{"Couldn't locate a .inscope file", err}
+	}
+
+	if !chainMode {
+		fmt.Print(".inscope found. Using " + inscopePath + "\n")
+	}
+
+	//look for .noscope file
+	noscopePath, err := searchForFileBackwards(".noscope")
+	if err != nil {
+		noscopePath = ""
+	} else if !chainMode {
+		fmt.Print(".noscope found. Using " + noscopePath + "\n")
+	}
+
+	return scopeFileSource{inscopePath: inscopePath, noscopePath: noscopePath}.Load()
+}
+
+// Merges every .inscope and .noscope file of the current directory and of its parents, such as an organization-wide .noscope in a parent directory, and a .inscope per engagement
+func loadParentScopes() (includes []scope.Rule, excludes []scope.Rule, err error) {
+	inscopePaths, err := searchForFilesBackwards(".inscope")
+	if err != nil || len(inscopePaths) == 0 {
+		return nil, nil, &usageError{"Couldn't locate a .inscope file", err}
+	}
+	noscopePaths, err := searchForFilesBackwards(".noscope")
+	if err != nil {
+		return nil, nil, &usageError{"Couldn't look for .noscope files", err}
+	}
+
+	for _, inscopePath := range inscopePaths {
+		if !chainMode {
+			fmt.Print(".inscope found. Using " + inscopePath + "\n")
+		}
+		fileIncludes, fileExcludes, err := scopeFileSource{inscopePath: inscopePath}.Load()
+		if err != nil {
+			return nil, nil, err
+		}
+		includes = append(includes, fileIncludes...)
+		excludes = append(excludes, fileExcludes...)
+	}
+	for _, noscopePath := range noscopePaths {
+		if !chainMode {
+			fmt.Print(".noscope found. Using " + noscopePath + "\n")
+		}
+		fileIncludes, fileExcludes, err := scopeFileSource{noscopePath: noscopePath}.Load()
+		if err != nil {
+			return nil, nil, err
+		}
+		includes = append(includes, fileIncludes...)
+		excludes = append(excludes, fileExcludes...)
+	}
+	return includes, excludes, nil
+}
+
+// scopeFileSource is a custom in-scopes file and/or out-of-scopes file. Either path may be empty, or a glob that matches several files.
+type scopeFileSource struct {
+	inscopePath string
+	noscopePath string
+}
+
+func (s scopeFileSource) Name() string {
+	if s.inscopePath == "" {
+		return "outofscope-file:" + s.noscopePath
+	}
+	return "inscope-file:" + s.inscopePath
+}
+
+// The negated ("!") scopes of the in-scopes file are out of scope, and the negated scopes of the out-of-scopes file are in scope
+func (s scopeFileSource) Load() (includes []scope.Rule, excludes []scope.Rule, err error) {
+	if s.inscopePath != "" {
+		includes, excludes, err = loadRulesFiles(s.inscopePath, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if s.noscopePath != "" {
+		noscopes, negated, err := loadRulesFiles(s.noscopePath, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		includes = append(includes, negated...)
+		excludes = append(excludes, noscopes...)
+	}
+	return includes, excludes, nil
+}
+
+// companySource is every company of the firebounty (or bounty-targets-data) database whose name contains the company string
+type companySource struct {
+	company        string
+	databaseFolder string
+}
+
+func (s companySource) Name() string {
+	return "company:" + s.company
+}
+
+func (s companySource) Load() (includes []scope.Rule, excludes []scope.Rule, err error) {
+	firebountyJSON, err := loadDatabase(s.databaseFolder)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selectedCompanies, err := selectCompanies(s.company, firebountyJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	//combine the scopes of every selected company as if they were a single company
+	for _, companyIndex := range selectedCompanies {
+		companyIncludes, companyExcludes, err := parseCompany(s.company, firebountyJSON, companyIndex)
+		if err != nil {
+			return nil, nil, err
+		}
+		includes = append(includes, companyIncludes...)
+		excludes = append(excludes, companyExcludes...)
+	}
+	return includes, excludes, nil
+}
+
+// Loads the programs of the firebounty database, or of the bounty-targets-data files of the database folder
+func loadDatabase(databaseFolder string) (Firebounty, error) {
+	if databaseFormat == "bounty-targets-data" {
+		return loadBountyTargetsData(databaseFolder)
+	}
+	return loadFirebountyJSON()
+}
+
+// programSelection picks the programs of the database without asking the user, so that company lookups can run without a TTY
+type programSelection struct {
+	// slug and url must be equal to the slug or to the URL (or firebounty URL) of the program
+	slug string
+	url  string
+	// exactName requires the name of the program to be the company string (ignoring case and accents), instead of being searched
+	exactName bool
+	// nameRegex is matched against the name of the program, instead of searching the company string
+	nameRegex *regexp.Regexp
+	// index picks a single program out of the matching programs. It's -1 when not set.
+	index int
+	// allMatches combines every matching program as if they were a single company
+	allMatches bool
+}
+
+var selection = programSelection{index: -1}
+
+// hasFilters reports whether the programs can be searched without a company string
+func (s programSelection) hasFilters() bool {
+	return s.slug != "" || s.url != "" || s.nameRegex != nil
+}
+
+// Scores how well the program matches the company and the filters, from 0 (no match) to 100. The field that matched best is also returned.
+func (s programSelection) score(company string, program Program) (int, string) {
+	if s.slug != "" && !strings.EqualFold(program.Slug, s.slug) {
+		return 0, ""
+	}
+	if s.url != "" && !sameURL(program.Url, s.url) && !sameURL(program.Firebounty_url, s.url) {
+		return 0, ""
+	}
+
+	switch {
+	case s.nameRegex != nil:
+		if s.nameRegex.MatchString(program.Name) {
+			return scoreExact, "name"
+		}
+		return 0, ""
+	case s.exactName:
+		if compactSearchText(program.Name) == compactSearchText(company) {
+			return scoreExact, "name"
+		}
+		return 0, ""
+	case company == "":
+		//the program was picked by the filters alone
+		return scoreExact, ""
+	}
+	return searchScore(company, program)
+}
+
+// sameURL compares two URLs, ignoring their case and their trailing slashes
+func sameURL(a string, b string) bool {
+	return a != "" && strings.EqualFold(strings.TrimSuffix(a, "/"), strings.TrimSuffix(b, "/"))
+}
+
+// Searches the company in the name, slug, tag and domain of every program, and returns the indexes of the selected programs. The matching programs are ranked by their score.
+// If several programs match (or only a few with typos), and no --program-index or --all-matches was given, the user is asked to pick one of them, or all of them.
+func selectCompanies(company string, firebountyJSON Firebounty) ([]int, error) {
+	var matchingCompanyList []firebountySearchMatch
+	var selectedCompanies []int
+	var userChoice string
+	var userPickedInvalidChoice bool = true
+	var userChoiceAsInt int
+
+	//for every company...
+	for companyCounter := 0; companyCounter < len(firebountyJSON.Pgms); companyCounter++ {
+		if score, field := selection.score(company, firebountyJSON.Pgms[companyCounter]); score > 0 {
+			matchingCompanyList = append(matchingCompanyList, firebountySearchMatch{companyCounter, firebountyJSON.Pgms[companyCounter].Name, score, field})
+		}
+	}
+	sort.SliceStable(matchingCompanyList, func(i, j int) bool {
+		return matchingCompanyList[i].score > matchingCompanyList[j].score
+	})
+
+	//the typos are only tolerated when nothing else matched
+	onlyTypos := len(matchingCompanyList) > 0 && matchingCompanyList[0].score <= scoreFuzzy
+	if !onlyTypos {
+		matchingCompanyList = slices.DeleteFunc(matchingCompanyList, func(match firebountySearchMatch) bool {
+			return match.score <= scoreFuzzy
+		})
+	}
+
+	if len(matchingCompanyList) == 0 {
+		if !chainMode {
+			fmt.Println(string(colorRed) + "[-] No program name, slug, tag or domain matched \"" + company + "\", not even with typos" + string(colorReset))
+			fmt.Println(string(colorRed) + "[-] Consider either of these options:")
+			fmt.Println(string(colorRed) + "\t - Doing a manual search at https://firebounty.com")
+			fmt.Println(string(colorRed) + "\t - Loading the scopes manually into '.inscope' and '.noscope' files.")
+			fmt.Println(string(colorRed) + "\t - Loading the scopes manually into custom files, specified with the --inscope-file and --outofscope-file arguments." + string(colorReset))
+		}
+		return nil, &usageError{"Unable to find the company \"" + company + "\"", errCompanyNotFound}
+	}
+
+	//the programs may be picked with the arguments, so that no user input is needed
+	if selection.allMatches {
+		for _, match := range matchingCompanyList {
+			selectedCompanies = append(selectedCompanies, match.companyIndex)
+		}
+		return selectedCompanies, nil
+	}
+	if selection.index >= 0 {
+		if selection.index >= len(matchingCompanyList) {
+			return nil, &usageError{"The program index " + strconv.Itoa(selection.index) + " is out of range. Only " + strconv.Itoa(len(matchingCompanyList)) + " programs matched: " + describeMatches(matchingCompanyList), errAmbiguousCompany}
+		}
+		return []int{matchingCompanyList[selection.index].companyIndex}, nil
+	}
+
+	if len(matchingCompanyList) > 1 || onlyTypos {
+
+		//the user can't be asked without a terminal, and stdin may be the targets list
+		if chainMode || usedstdin || (!stdinIsTerminal() && !isVSCodeDebug()) {
+			if onlyTypos {
+				return nil, &usageError{"Unable to find the company \"" + company + "\". Did you mean: " + describeMatches(matchingCompanyList) + "? Pick them with --program-index or --all-matches", errCompanyNotFound}
+			}
+			return nil, &usageError{"Unable to match the company to a single company. Please use a more exact company string, or pick the programs with --program-slug, --program-url, --exact-name, --name-regex, --program-index or --all-matches. Matched programs: " + describeMatches(matchingCompanyList), errAmbiguousCompany}
+		}
+
+		if onlyTypos {
+			fmt.Println(string(colorYellow) + "[-] No program matched \"" + company + "\" exactly. These are the closest matches:" + string(colorReset))
+		}
+
+		//appearently "while" doesn't exist in Go. It has been replaced by "for"
+		for userPickedInvalidChoice {
+			//For every matchingCompanyList item, from the best to the worst match...
+			for i := 0; i < len(matchingCompanyList); i++ {
+				//Print it
+				fmt.Println("    " + strconv.Itoa(i) + " - " + describeMatch(matchingCompanyList[i]))
+			}
+
+			//Show user the option to combine all of the previous companies as if they were a single company
+			fmt.Println("    " + strconv.Itoa(len(matchingCompanyList)) + " - COMBINE ALL")
+
+			//Get userchoice
+			fmt.Print("\n[+] Multiple companies matched \"" + company + "\". Please choose one: ")
+			_, err := fmt.Scanln(&userChoice)
+			if err != nil {
+				return nil, &ioError{"An error ocurred while reading user input", err}
+			}
+
+			//Convert userchoice str -> int
+			userChoiceAsInt, err = strconv.Atoi(userChoice)
+			//If the user picked something invalid...
+			if err != nil || userChoiceAsInt < 0 || userChoiceAsInt > len(matchingCompanyList) {
+				warning("Invalid option selected!")
+			} else {
+				userPickedInvalidChoice = false
+			}
+		}
+
+		//tip
+		fmt.Println("[-] If you want to remove one of these options, feel free to modify your database: " + firebountyJSON.Pgms[matchingCompanyList[0].companyIndex].databasePath)
+		fmt.Println("[-] To skip this question next time, use --program-index " + strconv.Itoa(userChoiceAsInt) + " (or --all-matches to combine all of them)\n")
+
+		//If the user chose to "COMBINE ALL"...
+		if userChoiceAsInt == len(matchingCompanyList) {
+			//for every company that matched the company query...
+			for i := 0; i < len(matchingCompanyList); i++ {
+
+				//Load the matchingCompanyList 2D slice, and convert the first member from string to integer, and save the company index
+				companyIndex := matchingCompanyList[i].companyIndex
+				selectedCompanies = append(selectedCompanies, companyIndex)
+			}
+		} else {
+
+			//Use userChoiceAsInt as an index for the matchingCompanyList 2D slice, and save the company index
+			companyCounter := matchingCompanyList[userChoiceAsInt].companyIndex
+			selectedCompanies = append(selectedCompanies, companyCounter)
+		}
+
+	} else {
+		//Only 1 company matched the query
+		selectedCompanies = append(selectedCompanies, matchingCompanyList[0].companyIndex)
+	}
+
+	return selectedCompanies, nil
+}
+
+// Lists the matching programs along with their index, such as "0 - Tesla (score 100, name), 1 - Tesla Motors (score 90, name)"
+func describeMatches(matchingCompanyList []firebountySearchMatch) string {
+	descriptions := make([]string, len(matchingCompanyList))
+	for i, match := range matchingCompanyList {
+		descriptions[i] = strconv.Itoa(i) + " - " + describeMatch(match)
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// Describes a matching program along with its score, such as "Tesla (score 100, name)"
+func describeMatch(match firebountySearchMatch) string {
+	if match.field == "" {
+		return match.companyName
+	}
+	return match.companyName + " (score " + strconv.Itoa(match.score) + ", " + match.field + ")"
+}
+
+// platformSource is a program of a bug bounty platform, loaded from its API
+type platformSource struct {
+	platform string
+	handle   string
+}
+
+func (s platformSource) Name() string {
+	return s.platform + ":" + s.handle
+}
+
+func (s platformSource) Load() (includes []scope.Rule, excludes []scope.Rule, err error) {
+	return loadPlatformProgram(s.platform, s.handle)
+}
+
+// urlSource is a plaintext list of in-scopes served over HTTP, such as a scopes file of a shared repository
+type urlSource struct {
+	url string
+}
+
+func (s urlSource) Name() string {
+	return "url:" + s.url
+}
+
+func (s urlSource) Load() (includes []scope.Rule, excludes []scope.Rule, err error) {
+	if !strings.HasPrefix(s.url, "http://") && !strings.HasPrefix(s.url, "https://") {
+		return nil, nil, &usageError{"Invalid scopes URL \"" + s.url + "\". Only http and https URLs are supported", nil}
+	}
+
+	response, err := http.Get(s.url) // #nosec G107 -- The URL is a CLI argument specified by the user running the program.
+	if err != nil {
+		return nil, nil, &databaseError{"Could not download the scopes from " + s.url, err}
+	}
+	defer response.Body.Close() // #nosec G307 -- The body is only read from.
+
+	if response.StatusCode != http.StatusOK {
+		return nil, nil, &databaseError{"Could not download the scopes from " + s.url + ": " + response.Status, nil}
+	}
+
+	includes, excludes, err = readRules(response.Body, s.url, nil)
+	if err != nil {
+		return nil, nil, &databaseError{"Could not download the scopes from " + s.url, err}
+	}
+	return includes, excludes, nil
+}
+
+// outOfScopesFileOverride replaces the out-of-scopes of a source with the ones of the user's own out-of-scopes files
+type outOfScopesFileOverride struct {
+	ScopeSource
+	paths []string
+}
+
+func (s outOfScopesFileOverride) Load() (includes []scope.Rule, excludes []scope.Rule, err error) {
+	includes, _, err = s.ScopeSource.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, path := range s.paths {
+		noscopes, negated, err := loadRulesFiles(path, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		includes = append(includes, negated...)
+		excludes = append(excludes, noscopes...)
+	}
+	return includes, excludes, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func Test_parseSource(t *testing.T) {
+	source, err := parseSource("company:Tesla", "/db/")
+	checkForErrors(t, err)
+	equals(t, companySource{company: "tesla", databaseFolder: "/db/"}, source)
+
+	source, err = parseSource("hackerone:security", "")
+	checkForErrors(t, err)
+	equals(t, "hackerone:security", source.Name())
+
+	source, err = parseSource("url:https://example.com/scopes.txt", "")
+	checkForErrors(t, err)
+	equals(t, urlSource{url: "https://example.com/scopes.txt"}, source)
+
+	source, err = parseSource(".inscope", "")
+	checkForErrors(t, err)
+	equals(t, dotScopeSource{}, source)
+
+	for _, spec := range []string{"company", "company:", "openbugbounty:tesla"} {
+		_, err = parseSource(spec, "")
+		equals(t, exitUsage, exitCode(err))
+	}
+}
+
+func Test_loadSources(t *testing.T) {
+	setGlobal(t, &chainMode, true)
+	setGlobal(t, &platform, "firebounty")
+	directory := t.TempDir()
+	inscopePath := filepath.Join(directory, "inscope.txt")
+	noscopePath := filepath.Join(directory, "noscope.txt")
+	checkForErrors(t, os.WriteFile(inscopePath, []byte("*.example.com\n"), 0600))
+	checkForErrors(t, os.WriteFile(noscopePath, []byte("admin.example.com\n"), 0600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/scopes.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("example.org\n\n192.0.2.0/24\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	sources, err := scopeSources([]string{"url:" + server.URL + "/scopes.txt"}, "", []string{inscopePath}, []string{noscopePath}, "")
+	checkForErrors(t, err)
+	equals(t, 3, len(sources))
+
+	includes, excludes, err := loadSources(sources)
+	checkForErrors(t, err)
+	equals(t, 3, len(includes))
+	equals(t, server.URL+"/scopes.txt", includes[0].Source)
+	equals(t, 3, includes[1].Line)
+	equals(t, inscopePath, includes[2].Source)
+	equals(t, 1, len(excludes))
+	equals(t, noscopePath, excludes[0].Source)
+
+	_, _, err = urlSource{url: server.URL + "/missing.txt"}.Load()
+	equals(t, exitDatabase, exitCode(err))
+	_, _, err = urlSource{url: "file:///etc/passwd"}.Load()
+	equals(t, exitUsage, exitCode(err))
+}
+
+func Test_outOfScopesFileOverride(t *testing.T) {
+	setGlobal(t, &chainMode, true)
+	directory := t.TempDir()
+	inscopePath := filepath.Join(directory, "inscope.txt")
+	noscopePath := filepath.Join(directory, "noscope.txt")
+	overridePath := filepath.Join(directory, "override.txt")
+	checkForErrors(t, os.WriteFile(inscopePath, []byte("*.example.com\n"), 0600))
+	checkForErrors(t, os.WriteFile(noscopePath, []byte("admin.example.com\n"), 0600))
+	checkForErrors(t, os.WriteFile(overridePath, []byte("dev.example.com\nstaging.example.com\n"), 0600))
+
+	includes, excludes, err := outOfScopesFileOverride{scopeFileSource{inscopePath, noscopePath}, []string{overridePath}}.Load()
+	checkForErrors(t, err)
+	equals(t, 1, len(includes))
+	equals(t, 2, len(excludes))
+	equals(t, overridePath, excludes[0].Source)
+}
+
+func Test_loadParentScopes(t *testing.T) {
+	setGlobal(t, &chainMode, true)
+	organization := t.TempDir()
+	engagement := filepath.Join(organization, "engagement")
+	checkForErrors(t, os.Mkdir(engagement, 0700))
+	checkForErrors(t, os.WriteFile(filepath.Join(organization, ".noscope"), []byte("*.internal.example.com\n"), 0600))
+	checkForErrors(t, os.WriteFile(filepath.Join(organization, ".inscope"), []byte("*.example.org\n"), 0600))
+	checkForErrors(t, os.WriteFile(filepath.Join(engagement, ".inscope"), []byte("*.example.com\n"), 0600))
+
+	workingDirectory, err := os.Getwd()
+	checkForErrors(t, err)
+	checkForErrors(t, os.Chdir(engagement))
+	t.Cleanup(func() { _ = os.Chdir(workingDirectory) })
+
+	// only the nearest files are used by default
+	includes, excludes, err := dotScopeSource{}.Load()
+	checkForErrors(t, err)
+	equals(t, 1, len(includes))
+	equals(t, filepath.Join(engagement, ".inscope"), includes[0].Source)
+	equals(t, 1, len(excludes))
+
+	includes, excludes, err = dotScopeSource{mergeParents: true}.Load()
+	checkForErrors(t, err)
+	equals(t, 2, len(includes))
+	equals(t, filepath.Join(engagement, ".inscope"), includes[0].Source)
+	equals(t, filepath.Join(organization, ".inscope"), includes[1].Source)
+	equals(t, 1, len(excludes))
+}
+
+func Test_selectCompanies(t *testing.T) {
+	setGlobal(t, &chainMode, true)
+	database, err := loadBountyTargetsData(filepath.Join("testdata", "bounty-targets-data"))
+	checkForErrors(t, err)
+
+	// "e" matches Acme Corp, Tesla, YesWeHack and Federacy
+	_, err = selectCompanies("e", database)
+	equals(t, true, errors.Is(err, errAmbiguousCompany))
+
+	tests := []struct {
+		company   string
+		selection programSelection
+		exp       []int
+	}{
+		{"e", programSelection{index: 1}, []int{1}},
+		{"e", programSelection{index: -1, allMatches: true}, []int{0, 1, 3, 4}},
+		{"tesla", programSelection{index: -1, exactName: true}, []int{1}},
+		{"", programSelection{index: -1, slug: "ACME"}, []int{0}},
+		{"", programSelection{index: -1, slug: "yeswehack"}, []int{3}},
+		{"", programSelection{index: -1, url: "https://bugcrowd.com/tesla/"}, []int{1}},
+		{"", programSelection{index: -1, nameRegex: regexp.MustCompile(`^(Tesla|Federacy)$`), allMatches: true}, []int{1, 4}},
+	}
+	for _, test := range tests {
+		setGlobal(t, &selection, test.selection)
+		selected, err := selectCompanies(test.company, database)
+		checkForErrors(t, err)
+		equals(t, test.exp, selected)
+	}
+
+	setGlobal(t, &selection, programSelection{index: 4})
+	_, err = selectCompanies("e", database)
+	equals(t, exitUsage, exitCode(err))
+
+	setGlobal(t, &selection, programSelection{index: -1, exactName: true})
+	_, err = selectCompanies("tes", database)
+	equals(t, true, errors.Is(err, errCompanyNotFound))
+}
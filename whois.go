@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+)
+
+var whoisMode bool
+
+// programMatch is a program whose scopes matched a target. It's also the JSON representation of the match.
+type programMatch struct {
+	Program       string `json:"program"`
+	Slug          string `json:"slug,omitempty"`
+	FirebountyURL string `json:"firebounty_url,omitempty"`
+	URL           string `json:"url,omitempty"`
+	// Verdict is "in" for the programs that own the target, and "out" for the programs that explicitly excluded it
+	Verdict  string `json:"verdict"`
+	Rule     string `json:"rule"`
+	RuleType string `json:"rule_type"`
+	Source   string `json:"source,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// whoisResult is the JSON representation of the programs that matched a target
+type whoisResult struct {
+	Input string `json:"input"`
+	Host  string `json:"host,omitempty"`
+	// Type and Identifier are only set for non-web targets (see --target-type)
+	Type       string         `json:"type,omitempty"`
+	Identifier string         `json:"identifier,omitempty"`
+	Programs   []programMatch `json:"programs"`
+	Detail     string         `json:"detail,omitempty"`
+}
+
+// programMatcher classifies targets with the scopes of a single program of the database
+type programMatcher struct {
+	program *Program
+	matcher *scope.Matcher
+}
+
+// Compiles the scopes of every program in the database, and their whitelisted domains with --include-whitelisted. The programs without any valid scope are skipped.
+func newProgramMatchers(database Firebounty, options scope.Options) ([]programMatcher, error) {
+	var matchers []programMatcher
+	for i := range database.Pgms {
+		program := &database.Pgms[i]
+		includes := programRules(program.Scopes.In_scopes, program)
+		if includeWhitelisted {
+			whitelisted := programRules(whitelistedScopes(database, *program), program)
+			for j := range whitelisted {
+				whitelisted[j].Source += ":white_listed"
+			}
+			includes = append(includes, whitelisted...)
+		}
+		if len(includes) == 0 {
+			continue
+		}
+		matcher, err := scope.NewMatcher(includes, programRules(program.Scopes.Out_of_scopes, program), options)
+		if err != nil {
+			return nil, &usageError{"Couldn't compile the scopes of " + program.Name, err}
+		}
+		matchers = append(matchers, programMatcher{program, matcher})
+	}
+	return matchers, nil
+}
+
+// Parses the scopes of a program. Unlike parseCompany, the scopes that can't be parsed, or whose type isn't supported, are silently skipped, since every program of the database is parsed at once.
+func programRules(scopes []Scope, program *Program) []scope.Rule {
+	var rules []scope.Rule
+	for _, programScope := range scopes {
+		asset, supported := scopeAssetType(programScope.Scope_type)
+		if !supported || programScope.Scope == "" {
+			continue
+		}
+		rule, err := scope.ParseAssetRule(programScope.Scope, asset)
+		if err != nil {
+			continue
+		}
+		rule.Source = program.source
+		rule.Program = program.Name
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// Runs the target against every program, and returns the programs that own it, or that explicitly excluded it
+func whoisTarget(matchers []programMatcher, line string) whoisResult {
+	result := whoisResult{Input: line, Programs: []programMatch{}}
+	target, err := scope.ParseAssetTarget(line, targetAsset)
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	result.Host = target.Host
+	if target.Asset != scope.WebAsset {
+		result.Type, result.Identifier = target.Asset.String(), target.Identifier
+	}
+
+	for _, m := range matchers {
+		verdict, reason := m.matcher.ClassifyTarget(target)
+		//unsure targets are unrelated to the program
+		if reason.Rule == nil || (verdict != scope.InScope && verdict != scope.OutOfScope) {
+			continue
+		}
+		result.Programs = append(result.Programs, programMatch{
+			Program:       m.program.Name,
+			Slug:          m.program.Slug,
+			FirebountyURL: m.program.Firebounty_url,
+			URL:           m.program.Url,
+			Verdict:       verdict.String(),
+			Rule:          reason.Rule.Raw,
+			RuleType:      reason.Rule.Kind.String(),
+			Source:        reason.Rule.Source,
+			Detail:        reason.Detail,
+		})
+	}
+	return result
+}
+
+// Finds the programs of the database that own every target. The targets are read from the arguments, or line per line from targetsFile.
+func runWhois(targets []string, targetsFile io.Reader, database Firebounty, options scope.Options) error {
+	matchers, err := newProgramMatchers(database, options)
+	if err != nil {
+		return err
+	}
+
+	var results []whoisResult
+	handle := func(line string) error {
+		result := whoisTarget(matchers, line)
+		for _, match := range result.Programs {
+			foundInScope = foundInScope || match.Verdict == scope.InScope.String()
+		}
+		switch outputFormat {
+		case "json":
+			results = append(results, result)
+		case "jsonl":
+			if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+				return &ioError{"Unable to write the results", err}
+			}
+		default:
+			printWhoisResult(result)
+		}
+		return nil
+	}
+
+	if len(targets) > 0 {
+		for _, target := range targets {
+			if err := handle(target); err != nil {
+				return err
+			}
+		}
+	} else {
+		targetsScanner := bufio.NewScanner(targetsFile)
+		for targetsScanner.Scan() {
+			if strings.TrimSpace(targetsScanner.Text()) == "" {
+				continue
+			}
+			if err := handle(targetsScanner.Text()); err != nil {
+				return err
+			}
+		}
+		if err := targetsScanner.Err(); err != nil {
+			return &ioError{"Could not read URL List file successfully", err}
+		}
+	}
+
+	if outputFormat == "json" {
+		if results == nil {
+			results = []whoisResult{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			return &ioError{"Unable to write the results", err}
+		}
+	}
+	return inscopeResult()
+}
+
+// Prints the programs that matched a target. In chain-mode, only the programs that own the target are printed, one per line, as "target<TAB>program<TAB>url".
+func printWhoisResult(result whoisResult) {
+	if chainMode {
+		for _, match := range result.Programs {
+			if match.Verdict == scope.InScope.String() {
+				fmt.Println(result.Input + "\t" + match.Program + "\t" + programLink(match))
+			}
+		}
+		return
+	}
+
+	if result.Detail != "" {
+		warning(result.Detail)
+		return
+	}
+	if len(result.Programs) == 0 {
+		fmt.Println("[-] " + result.Input + ": no program of the database owns this target")
+		return
+	}
+	for _, match := range result.Programs {
+		description := match.Program + " (" + programLink(match) + "), matched by " + strconv.Quote(match.Rule) + " from " + match.Source
+		if match.Verdict == scope.InScope.String() {
+			infoGood("IN-SCOPE: ", result.Input+" belongs to "+description)
+		} else {
+			infoWarning("OUT-OF-SCOPE: ", result.Input+" is excluded by "+description)
+		}
+	}
+}
+
+// The firebounty URL of the program, or the URL on its platform for the bounty-targets-data programs
+func programLink(match programMatch) string {
+	if match.FirebountyURL != "" {
+		return match.FirebountyURL
+	}
+	return match.URL
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+)
+
+func Test_whoisTarget(t *testing.T) {
+	database, err := loadBountyTargetsData(filepath.Join("testdata", "bounty-targets-data"))
+	checkForErrors(t, err)
+	matchers, err := newProgramMatchers(database, scope.Options{ExplicitLevel: 1})
+	checkForErrors(t, err)
+
+	result := whoisTarget(matchers, "https://sub.acme.com/login")
+	equals(t, "sub.acme.com", result.Host)
+	equals(t, 1, len(result.Programs))
+	equals(t, programMatch{Program: "Acme Corp", Slug: "acme", URL: "https://hackerone.com/acme", Verdict: "in", Rule: "*.acme.com", RuleType: "wildcard", Source: "hackerone_data.json"}, result.Programs[0])
+
+	result = whoisTarget(matchers, "shop.tesla.com")
+	equals(t, 1, len(result.Programs))
+	equals(t, "Tesla", result.Programs[0].Program)
+	equals(t, "out", result.Programs[0].Verdict)
+
+	result = whoisTarget(matchers, "203.0.113.7")
+	equals(t, 1, len(result.Programs))
+	equals(t, "203.0.113.0/24", result.Programs[0].Rule)
+
+	// the android packages of the programs aren't web scopes
+	equals(t, 0, len(whoisTarget(matchers, "com.acme.android").Programs))
+	equals(t, 0, len(whoisTarget(matchers, "example.org").Programs))
+
+	// the android packages are found with android targets
+	setGlobal(t, &targetAsset, scope.AndroidAsset)
+	result = whoisTarget(matchers, "https://play.google.com/store/apps/details?id=com.yeswehack.android")
+	equals(t, "android", result.Type)
+	equals(t, "com.yeswehack.android", result.Identifier)
+	equals(t, 1, len(result.Programs))
+	equals(t, "YesWeHack", result.Programs[0].Program)
+	equals(t, "android-package", result.Programs[0].RuleType)
+	setGlobal(t, &targetAsset, scope.WebAsset)
+
+	setGlobal(t, &chainMode, true)
+	setGlobal(t, &outputFormat, "text")
+	setGlobal(t, &foundInScope, false)
+	equals(t, exitNoneInScope, exitCode(runWhois(nil, strings.NewReader("shop.tesla.com\n\nexample.org\n"), database, scope.Options{ExplicitLevel: 1})))
+	checkForErrors(t, runWhois([]string{"www.tesla.com"}, nil, database, scope.Options{ExplicitLevel: 1}))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ItsIgnacioPortal/hacker-scoper/scope"
+)
+
+const yeswehackDefaultAPIURL = "https://api.yeswehack.com"
+
+var yeswehackAPIURL string
+
+// yeswehackProgram is a program of the YesWeHack API, as returned by "https://api.yeswehack.com/programs/<slug>"
+type yeswehackProgram struct {
+	Title  string           `json:"title"`
+	Slug   string           `json:"slug"`
+	Scopes []yeswehackScope `json:"scopes"`
+	// OutOfScope is free text, such as "*.staging.example.com" or "Any third-party service"
+	OutOfScope []string `json:"out_of_scope"`
+}
+
+// yeswehackScope is an in-scope asset. Its type is one of "web-application", "api", "ip-address", "wildcard", "mobile-application-android", "mobile-application-ios", "other"...
+type yeswehackScope struct {
+	Scope     string `json:"scope"`
+	ScopeType string `json:"scope_type"`
+}
+
+// the scope types that are web hosts
+var yeswehackWebScopeTypes = map[string]bool{
+	"web-application": true,
+	"api":             true,
+	"ip-address":      true,
+	"wildcard":        true,
+}
+
+// Loads the scopes of a YesWeHack program, and returns its in-scope and out-of-scope rules. The scopes are downloaded if the cached copy is missing or older than 24hs.
+func loadYesWeHackProgram(slug string) (includes []scope.Rule, excludes []scope.Rule, err error) {
+	cachePath := platformCachePath("yeswehack", slug)
+	var program yeswehackProgram
+	cached, err := readPlatformCache(cachePath, &program)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !cached {
+		credentials, err := loadCredentials("yeswehack", "", "YESWEHACK_TOKEN")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !chainMode {
+			fmt.Println("[INFO]: Downloading the scopes of \"" + slug + "\" from YesWeHack and saving them in \"" + cachePath + "\"")
+		}
+		program, err = downloadYesWeHackProgram(yeswehackAPIURL, slug, credentials.Token)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writePlatformCache(cachePath, program); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !chainMode {
+		fmt.Print("[+] Loaded the YesWeHack program " + string(colorGreen) + program.Title + string(colorReset) + "!\n")
+		fmt.Println("[+] Program URL: https://yeswehack.com/programs/" + slug)
+
+		fmt.Println("[+] In-scope rules: ")
+		for _, s := range program.Scopes {
+			fmt.Println("\t[+] " + s.ScopeType + ": " + s.Scope)
+		}
+
+		fmt.Println("\n[+] Out-of-scope rules: ")
+		for _, s := range program.OutOfScope {
+			fmt.Println("\t[+] " + s)
+		}
+
+		fmt.Println("\n[+] Analysis started...")
+	}
+
+	includes, excludes = yeswehackRules(slug, program)
+	return includes, excludes, nil
+}
+
+func downloadYesWeHackProgram(apiURL string, slug string, token string) (yeswehackProgram, error) {
+	body, err := platformGet("YesWeHack", strings.TrimSuffix(apiURL, "/")+"/programs/"+url.PathEscape(slug), slug, func(request *http.Request) {
+		request.Header.Set("Authorization", "Bearer "+token)
+	})
+	if err != nil {
+		return yeswehackProgram{}, err
+	}
+
+	var program yeswehackProgram
+	if err := json.Unmarshal(body, &program); err != nil {
+		return yeswehackProgram{}, &databaseError{"Couldn't parse the YesWeHack program \"" + slug + "\"", err}
+	}
+	return program, nil
+}
+
+// Maps the scopes into rules. The out-of-scopes are free text, so only the ones that look like a host, URL or IP address are used.
+func yeswehackRules(slug string, program yeswehackProgram) (includes []scope.Rule, excludes []scope.Rule) {
+	skippedScopes := make(map[string]int)
+	for _, s := range program.Scopes {
+		includes = append(includes, parsePlatformScope(strings.TrimSpace(s.Scope), s.ScopeType, yeswehackWebScopeTypes[s.ScopeType], "yeswehack", slug, skippedScopes)...)
+	}
+	warnSkippedScopes(skippedScopes, slug)
+
+	for _, outOfScope := range program.OutOfScope {
+		outOfScope = strings.TrimSpace(outOfScope)
+		if outOfScope == "" || strings.Contains(outOfScope, " ") {
+			continue
+		}
+		rule, err := scope.ParseRule(outOfScope)
+		if err != nil {
+			continue
+		}
+		rule.Source = "yeswehack"
+		rule.Program = slug
+		excludes = append(excludes, rule)
+	}
+	return includes, excludes
+}